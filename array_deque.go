@@ -0,0 +1,40 @@
+package collections
+
+// ArrayDeque a deque based on the circular array of ArrayQueue. This implementation is not threadsafe.
+type ArrayDeque[T any] struct {
+	ArrayQueue[T]
+}
+
+func NewArrayDeque[T any]() *ArrayDeque[T] {
+	return &ArrayDeque[T]{
+		ArrayQueue: *NewArrayQueue[T](),
+	}
+}
+
+func NewArrayDequeWithInitialCapacity[T any](capacity uint) *ArrayDeque[T] {
+	return &ArrayDeque[T]{
+		ArrayQueue: *NewArrayQueueWithInitialCapacity[T](capacity),
+	}
+}
+
+func (q *ArrayDeque[T]) AddFirst(t T) {
+	if q.size == uint(len(q.array)) {
+		q.increaseCapacity()
+	}
+	q.head = (q.head - 1 + len(q.array)) % len(q.array)
+	q.array[q.head] = t
+	q.size++
+}
+
+func (q *ArrayDeque[T]) RemoveLast() (T, error) {
+	var zero T
+	if q.size == 0 {
+		return zero, &QueueError{Op: "RemoveLast", Err: ErrQueueEmpty}
+	}
+	q.tail = (q.tail - 1 + len(q.array)) % len(q.array)
+	x := q.array[q.tail]
+	q.array[q.tail] = zero
+	q.size--
+	q.shrinkIfNeeded()
+	return x, nil
+}
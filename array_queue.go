@@ -1,12 +1,15 @@
 package collections
 
-import "errors"
+import (
+	"iter"
+)
 
 type ArrayQueue[T any] struct {
-	array []T
-	head  int
-	tail  int
-	size  uint
+	array        []T
+	head         int
+	tail         int
+	size         uint
+	shrinkPolicy ShrinkPolicy
 }
 
 func NewArrayQueue[T any]() *ArrayQueue[T] {
@@ -30,44 +33,183 @@ func (q *ArrayQueue[T]) AddLast(t T) {
 	q.size++
 }
 
+// AddAll adds elements to the end of the queue, growing the backing array at most once.
+func (q *ArrayQueue[T]) AddAll(ts ...T) {
+	if len(ts) == 0 {
+		return
+	}
+	q.ensureCapacity(q.size + uint(len(ts)))
+	n := copy(q.array[q.tail:], ts)
+	copy(q.array, ts[n:])
+	q.tail = (q.tail + len(ts)) % len(q.array)
+	q.size += uint(len(ts))
+}
+
+// AddSeq adds all elements of the sequence to the end of the queue.
+func (q *ArrayQueue[T]) AddSeq(seq iter.Seq[T]) {
+	for t := range seq {
+		q.AddLast(t)
+	}
+}
+
 func (q *ArrayQueue[T]) RemoveFirst() (T, error) {
 	var zero T
 	if q.size == 0 {
-		return zero, errors.New("queue is empty")
+		return zero, &QueueError{Op: "RemoveFirst", Err: ErrQueueEmpty}
 	}
 	x := q.array[q.head]
 	q.array[q.head] = zero
 	q.head = (q.head + 1) % len(q.array)
 	q.size--
+	q.shrinkIfNeeded()
 	return x, nil
 }
 
+// RemoveN removes up to n first elements from the queue and returns them.
+func (q *ArrayQueue[T]) RemoveN(n int) []T {
+	result := make([]T, max(0, min(n, int(q.size))))
+	q.RemoveInto(result)
+	return result
+}
+
+// RemoveInto removes first elements from the queue into dst until either dst is full or the queue is empty.
+// Returns the number of removed elements.
+func (q *ArrayQueue[T]) RemoveInto(dst []T) int {
+	n := min(len(dst), int(q.size))
+	if n == 0 {
+		return 0
+	}
+	k := copy(dst[:n], q.array[q.head:])
+	copy(dst[k:n], q.array)
+	clear(q.array[q.head : q.head+k])
+	clear(q.array[:n-k])
+	q.head = (q.head + n) % len(q.array)
+	q.size -= uint(n)
+	q.shrinkIfNeeded()
+	return n
+}
+
+func (q *ArrayQueue[T]) PeekFirst() (T, error) {
+	var zero T
+	if q.size == 0 {
+		return zero, &QueueError{Op: "PeekFirst", Err: ErrQueueEmpty}
+	}
+	return q.array[q.head], nil
+}
+
+func (q *ArrayQueue[T]) PeekLast() (T, error) {
+	var zero T
+	if q.size == 0 {
+		return zero, &QueueError{Op: "PeekLast", Err: ErrQueueEmpty}
+	}
+	return q.array[(q.tail-1+len(q.array))%len(q.array)], nil
+}
+
+// Get returns i-th element of the queue counting from the first one, in constant time.
+func (q *ArrayQueue[T]) Get(i uint) (T, error) {
+	if i >= q.size {
+		var zero T
+		return zero, &IndexOutOfRangeError{Index: i, Size: q.size}
+	}
+	return q.array[(q.head+int(i))%len(q.array)], nil
+}
+
 func (q *ArrayQueue[T]) Size() uint {
 	return q.size
 }
 
-func (q *ArrayQueue[T]) increaseCapacity() {
-	length := len(q.array)
-	var zero T
-	if length == 0 {
-		q.array = append(q.array, zero)
-	} else {
-		if q.head > 0 {
-			for i := q.head; i < length; i++ {
-				q.array = append(q.array, q.array[i])
+// All returns an iterator over elements of the queue from the first to the last one without removing them.
+func (q *ArrayQueue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := 0; i < int(q.size); i++ {
+			if !yield(q.array[(q.head+i)%len(q.array)]) {
+				return
 			}
-			for i := 0; i < q.head; i++ {
-				q.array = append(q.array, q.array[i])
-			}
-			for i := 0; i < length; i++ {
-				q.array[i] = q.array[length+i]
+		}
+	}
+}
+
+// Backward returns an iterator over elements of the queue from the last to the first one without removing them.
+func (q *ArrayQueue[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := int(q.size) - 1; i >= 0; i-- {
+			if !yield(q.array[(q.head+i)%len(q.array)]) {
+				return
 			}
-		} else {
-			for i := 0; i < length; i++ {
-				q.array = append(q.array, zero)
+		}
+	}
+}
+
+// Drain returns an iterator that removes elements from the queue as it yields them.
+func (q *ArrayQueue[T]) Drain() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for q.size > 0 {
+			t, _ := q.RemoveFirst()
+			if !yield(t) {
+				return
 			}
 		}
 	}
+}
+
+// SetShrinkPolicy sets the policy deciding when the backing array is reallocated to a smaller one after removals.
+// A nil policy never shrinks.
+func (q *ArrayQueue[T]) SetShrinkPolicy(policy ShrinkPolicy) {
+	q.shrinkPolicy = policy
+}
+
+// TrimToSize reallocates the backing array so that its capacity equals the number of elements in the queue.
+func (q *ArrayQueue[T]) TrimToSize() {
+	q.resize(q.size)
+}
+
+func (q *ArrayQueue[T]) shrinkIfNeeded() {
+	if q.shrinkPolicy == nil {
+		return
+	}
+	capacity := uint(len(q.array))
+	if newCapacity := q.shrinkPolicy(q.size, capacity); newCapacity < capacity {
+		q.resize(max(newCapacity, q.size))
+	}
+}
+
+// overwriteFirst replaces the first element with t, which becomes the last one, and returns the replaced element.
+// The backing array must be full.
+func (q *ArrayQueue[T]) overwriteFirst(t T) T {
+	x := q.array[q.head]
+	q.array[q.head] = t
+	q.head = (q.head + 1) % len(q.array)
+	q.tail = q.head
+	return x
+}
+
+func (q *ArrayQueue[T]) increaseCapacity() {
+	q.ensureCapacity(q.size + 1)
+}
+
+func (q *ArrayQueue[T]) ensureCapacity(capacity uint) {
+	length := uint(len(q.array))
+	if capacity <= length {
+		return
+	}
+	if length == 0 && capacity <= uint(cap(q.array)) {
+		q.array = q.array[:cap(q.array)]
+		return
+	}
+	q.resize(max(capacity, 2*length))
+}
+
+// resize moves elements to a new array of the given capacity, which must not be smaller than the queue size.
+func (q *ArrayQueue[T]) resize(capacity uint) {
+	array := make([]T, capacity)
+	if q.size > 0 {
+		n := copy(array[:q.size], q.array[q.head:])
+		copy(array[n:q.size], q.array[:q.tail])
+	}
+	q.array = array
 	q.head = 0
-	q.tail = length
+	q.tail = 0
+	if q.size < capacity {
+		q.tail = int(q.size)
+	}
 }
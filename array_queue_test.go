@@ -0,0 +1,76 @@
+package collections
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestArrayQueue_Iterators(t *testing.T) {
+	var queue = NewArrayQueueWithInitialCapacity[int](4)
+	for i := 0; i < 3; i++ {
+		queue.AddLast(-1)
+		if _, err := queue.RemoveFirst(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		queue.AddLast(i)
+	}
+	if all := slices.Collect(queue.All()); !slices.Equal(all, orderedIntArray(10)) {
+		t.Fatalf("expected %v got %v", orderedIntArray(10), all)
+	}
+	if backward := slices.Collect(queue.Backward()); !slices.Equal(backward, reverse(orderedIntArray(10))) {
+		t.Fatalf("expected %v got %v", reverse(orderedIntArray(10)), backward)
+	}
+	if queue.Size() != 10 {
+		t.Fatalf("expected %d got %d", 10, queue.Size())
+	}
+	if drained := slices.Collect(queue.Drain()); !slices.Equal(drained, orderedIntArray(10)) {
+		t.Fatalf("expected %v got %v", orderedIntArray(10), drained)
+	}
+	if queue.Size() != 0 {
+		t.Fatalf("expected %d got %d", 0, queue.Size())
+	}
+}
+
+func TestArrayQueue_PeekAndGet(t *testing.T) {
+	var queue = NewArrayQueue[int]()
+	if _, err := queue.PeekFirst(); err == nil {
+		t.Fatalf("expected error when peeking into an empty queue")
+	}
+	if _, err := queue.PeekLast(); err == nil {
+		t.Fatalf("expected error when peeking into an empty queue")
+	}
+	for i := 0; i < 8; i++ {
+		queue.AddLast(i)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := queue.RemoveFirst(); err != nil {
+			t.Fatal(err)
+		}
+		queue.AddLast(8 + i)
+	}
+	if first, err := queue.PeekFirst(); err != nil || first != 5 {
+		t.Fatalf("expected %d got %d, %v", 5, first, err)
+	}
+	if last, err := queue.PeekLast(); err != nil || last != 12 {
+		t.Fatalf("expected %d got %d, %v", 12, last, err)
+	}
+	for i := uint(0); i < queue.Size(); i++ {
+		x, err := queue.Get(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != int(i)+5 {
+			t.Fatalf("expected %d got %d", i+5, x)
+		}
+	}
+	var indexErr *IndexOutOfRangeError
+	if _, err := queue.Get(queue.Size()); !errors.As(err, &indexErr) {
+		t.Fatalf("expected index out of range error, got %v", err)
+	}
+	if indexErr.Index != 8 || indexErr.Size != 8 {
+		t.Fatalf("unexpected error %v", indexErr)
+	}
+}
@@ -0,0 +1,83 @@
+package collections
+
+import (
+	"context"
+	"errors"
+)
+
+var errNilUndelivered = errors.New("undelivered must not be nil")
+
+// Out returns a channel receiving elements removed from the queue by a single goroutine, so that the queue can be
+// used in a select. The channel is closed once the queue is closed and empty, RemoveFirst fails otherwise, or ctx is
+// done. An element which has been removed from the queue but cannot be sent because ctx is done is passed to
+// undelivered instead of being lost, so undelivered must not be nil.
+func Out[T any](ctx context.Context, queue QueueWithLimit[T], undelivered func(T)) (<-chan T, error) {
+	if undelivered == nil {
+		return nil, errNilUndelivered
+	}
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		// RemoveFirst may return an available element even if ctx is done, so it is checked first
+		for ctx.Err() == nil {
+			t, err := queue.RemoveFirst(ctx)
+			if err != nil {
+				return
+			}
+			select {
+			case out <- t:
+			case <-ctx.Done():
+				// a receiver may still be waiting, otherwise nobody is going to receive the element
+				select {
+				case out <- t:
+				default:
+					undelivered(t)
+				}
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// In returns a channel whose elements are added to the queue by a single goroutine, so that the queue can be used
+// in a select. The goroutine stops once the channel is closed or ctx is done, after which sends block, so senders
+// should select on ctx.Done() too. Elements which cannot be added are passed to undelivered instead of being lost:
+// an element received when ctx ends and not fitting into the queue right away, and all elements received after
+// the queue has been closed. So undelivered must not be nil.
+func In[T any](ctx context.Context, queue QueueWithLimit[T], undelivered func(T)) (chan<- T, error) {
+	if undelivered == nil {
+		return nil, errNilUndelivered
+	}
+	in := make(chan T)
+	go func() {
+		closed := false
+		for {
+			select {
+			case t, ok := <-in:
+				if !ok {
+					return
+				}
+				if closed {
+					undelivered(t)
+					continue
+				}
+				err := queue.AddLast(ctx, t)
+				if err != nil && ctx.Err() != nil {
+					// the element has been received already, it is added only if that does not block
+					err = queue.TryAddLast(t)
+				}
+				if err != nil {
+					undelivered(t)
+					closed = errors.Is(err, ErrClosed)
+				}
+				if ctx.Err() != nil {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return in, nil
+}
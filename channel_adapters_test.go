@@ -0,0 +1,197 @@
+package collections
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestOut(t *testing.T) {
+	q, err := NewArrayQueueWithLimit[int](4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := q.TryAddLast(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.Close()
+	out, err := Out[int](context.Background(), q, func(x int) {
+		t.Errorf("unexpected undelivered element %d", x)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received []int
+	for x := range out {
+		received = append(received, x)
+	}
+	if !slices.Equal(received, []int{0, 1, 2}) {
+		t.Fatalf("expected %v got %v", []int{0, 1, 2}, received)
+	}
+}
+
+func TestOut_Cancel(t *testing.T) {
+	q := NewChannelledQueueWithLimit[int](4)
+	for i := 0; i < 3; i++ {
+		if err := q.TryAddLast(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	undelivered := make(chan int, 1)
+	out, err := Out[int](ctx, q, func(x int) {
+		undelivered <- x
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := <-out; x != 0 {
+		t.Fatalf("expected %d got %d", 0, x)
+	}
+	// the pump is blocked delivering the next element, nobody receives it after the cancellation
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case x := <-undelivered:
+		if x != 1 {
+			t.Fatalf("expected %d got %d", 1, x)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for the undelivered element")
+	}
+	if x, ok := <-out; ok {
+		t.Fatalf("expected the channel to be closed, got %d", x)
+	}
+	if q.Size() != 1 {
+		t.Fatalf("expected %d got %d", 1, q.Size())
+	}
+}
+
+func TestIn(t *testing.T) {
+	q, err := NewArrayQueueWithLimit[int](4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	in, err := In[int](ctx, q, func(x int) {
+		t.Errorf("unexpected undelivered element %d", x)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case in <- i:
+		case <-time.After(time.Second):
+			t.Fatalf("timed out sending %d", i)
+		}
+	}
+	close(in)
+	for i := 0; i < 3; i++ {
+		x, err := q.RemoveFirst(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != i {
+			t.Fatalf("expected %d got %d", i, x)
+		}
+	}
+}
+
+func TestIn_Cancel(t *testing.T) {
+	q, err := NewArrayQueueWithLimit[int](1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.TryAddLast(0); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	undelivered := make(chan int, 1)
+	in, err := In[int](ctx, q, func(x int) {
+		undelivered <- x
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the pump receives the element and blocks adding it to the full queue
+	in <- 1
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case x := <-undelivered:
+		if x != 1 {
+			t.Fatalf("expected %d got %d", 1, x)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for the undelivered element")
+	}
+	select {
+	case in <- 2:
+		t.Fatalf("expected the pump to be stopped")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if q.Size() != 1 {
+		t.Fatalf("expected %d got %d", 1, q.Size())
+	}
+}
+
+func TestIn_Closed(t *testing.T) {
+	q, err := NewArrayQueueWithLimit[int](1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	undelivered := make(chan int, 2)
+	in, err := In[int](context.Background(), q, func(x int) {
+		undelivered <- x
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in <- 0
+	for q.Size() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	q.Close()
+	// elements sent after the queue has been closed are reported instead of being lost
+	for i := 1; i < 3; i++ {
+		select {
+		case in <- i:
+		case <-time.After(time.Second):
+			t.Fatalf("timed out sending %d", i)
+		}
+		select {
+		case x := <-undelivered:
+			if x != i {
+				t.Fatalf("expected %d got %d", i, x)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for the undelivered element %d", i)
+		}
+	}
+	close(in)
+	x, err := q.RemoveFirst(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x != 0 {
+		t.Fatalf("expected %d got %d", 0, x)
+	}
+}
+
+func TestChannelAdapters_NilUndelivered(t *testing.T) {
+	q, err := NewArrayQueueWithLimit[int](1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if out, err := Out[int](ctx, q, nil); out != nil || err == nil {
+		t.Fatalf("expected an error, got %v %v", out, err)
+	}
+	if in, err := In[int](ctx, q, nil); in != nil || err == nil {
+		t.Fatalf("expected an error, got %v %v", in, err)
+	}
+}
@@ -3,58 +3,366 @@ package collections
 import (
 	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
 )
 
+// ChannelledQueueWithLimit an implementation of QueueWithLimit built on a buffered channel. Producers hold a read
+// lock while sending, so that Close can take the write lock and close the channel once no send is in progress.
+// Consumers do not lock at all.
+//
+// The capacity of a channel cannot change, so SetMaxSize replaces the channel. Consumers which find the channel they
+// received from closed retry on the current one if it has been replaced. Adds are paused only while SetMaxSize waits
+// for consumers to remove the elements which do not fit into a smaller channel.
 type ChannelledQueueWithLimit[T any] struct {
-	c chan T
+	c    atomic.Pointer[chan T]
+	lock sync.RWMutex
+	// resizeLock serializes SetMaxSize calls
+	resizeLock sync.Mutex
+	// paused is set while SetMaxSize waits for consumers, new adds wait until it is closed
+	paused atomic.Pointer[chan struct{}]
+	// kick is closed by SetMaxSize to wake up blocked producers, guarded by lock
+	kick chan struct{}
+	// notFull is notified whenever an element is removed and when the queue is closed
+	notFull *signal
+	// closing is closed first by Close, to wake up blocked producers before the write lock is taken
+	closing chan struct{}
+	// closed is closed after c
+	closed    chan struct{}
+	closedNow chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	nowOnce   sync.Once
+	doneOnce  sync.Once
+	observer  Observer
 }
 
 func NewChannelledQueueWithLimit[T any](maxSize uint) *ChannelledQueueWithLimit[T] {
-	return &ChannelledQueueWithLimit[T]{
-		c: make(chan T, maxSize),
+	q := &ChannelledQueueWithLimit[T]{
+		kick:      make(chan struct{}),
+		notFull:   newSignal(),
+		closing:   make(chan struct{}),
+		closed:    make(chan struct{}),
+		closedNow: make(chan struct{}),
+		done:      make(chan struct{}),
 	}
+	c := make(chan T, maxSize)
+	q.c.Store(&c)
+	return q
 }
 
 func (q *ChannelledQueueWithLimit[T]) AddLast(ctx context.Context, t T) error {
+	err := q.tryAddLast("AddLast", t)
+	if errors.Is(err, ErrQueueFull) {
+		err = observeWait(q.observer, "AddLast", func() error {
+			return q.addLast(ctx, t)
+		})
+	}
+	return q.added("AddLast", err)
+}
+
+// addLast blocks until t is added, retrying when SetMaxSize has replaced the channel.
+func (q *ChannelledQueueWithLimit[T]) addLast(ctx context.Context, t T) error {
+	for {
+		if paused := q.paused.Load(); paused != nil {
+			select {
+			case <-*paused:
+			case <-q.closing:
+				return q.closedError("AddLast")
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if added, err := q.send(ctx, t); added || err != nil {
+			return err
+		}
+	}
+}
+
+// send sends t to the current channel, it returns false without an error if SetMaxSize has interrupted it.
+func (q *ChannelledQueueWithLimit[T]) send(ctx context.Context, t T) (bool, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if isClosed(q.closing) {
+		return false, q.closedError("AddLast")
+	}
+	if q.paused.Load() != nil {
+		return false, nil
+	}
 	select {
-	case q.c <- t:
-		return nil
+	case *q.c.Load() <- t:
+		return true, nil
+	case <-q.kick:
+		return false, nil
+	case <-q.closing:
+		return false, q.closedError("AddLast")
 	case <-ctx.Done():
-		return ctx.Err()
+		return false, ctx.Err()
 	}
 }
 
 func (q *ChannelledQueueWithLimit[T]) TryAddLast(t T) error {
+	return q.added("TryAddLast", q.tryAddLast("TryAddLast", t))
+}
+
+func (q *ChannelledQueueWithLimit[T]) tryAddLast(op string, t T) error {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if isClosed(q.closing) {
+		return q.closedError(op)
+	}
+	if q.paused.Load() != nil {
+		return &QueueError{Op: op, Capacity: q.MaxSize(), Err: ErrQueueFull}
+	}
 	select {
-	case q.c <- t:
+	case *q.c.Load() <- t:
 	default:
-		return errors.New("queue is full")
+		return &QueueError{Op: op, Capacity: q.MaxSize(), Err: ErrQueueFull}
 	}
 	return nil
 }
 
+// added reports the result of an add to the observer and returns err.
+func (q *ChannelledQueueWithLimit[T]) added(op string, err error) error {
+	if q.observer != nil && err == nil {
+		q.observer.OnEnqueue(1, q.Size())
+	}
+	return observeReject(q.observer, op, err)
+}
+
 func (q *ChannelledQueueWithLimit[T]) RemoveFirst(ctx context.Context) (t T, err error) {
-	select {
-	case t = <-q.c:
-		return t, nil
-	case <-ctx.Done():
-		return t, ctx.Err()
+	t, err = q.tryRemoveFirst("RemoveFirst")
+	if errors.Is(err, ErrQueueEmpty) {
+		err = observeWait(q.observer, "RemoveFirst", func() error {
+			t, err = q.removeFirst(ctx)
+			return err
+		})
+	}
+	return t, q.removed(1, err)
+}
+
+// removeFirst blocks until an element is removed, retrying when SetMaxSize has replaced the channel.
+func (q *ChannelledQueueWithLimit[T]) removeFirst(ctx context.Context) (t T, err error) {
+	for {
+		if isClosed(q.closedNow) {
+			return t, q.closedError("RemoveFirst")
+		}
+		c := q.c.Load()
+		select {
+		case t, ok := <-*c:
+			if !ok && q.c.Load() != c {
+				continue
+			}
+			return q.received(t, ok, "RemoveFirst")
+		case <-q.closedNow:
+			return t, q.closedError("RemoveFirst")
+		case <-ctx.Done():
+			return t, ctx.Err()
+		}
 	}
 }
 
 func (q *ChannelledQueueWithLimit[T]) TryRemoveFirst() (t T, err error) {
-	select {
-	case t = <-q.c:
-		return t, nil
-	default:
-		return t, errors.New("queue is empty")
+	t, err = q.tryRemoveFirst("TryRemoveFirst")
+	return t, q.removed(1, err)
+}
+
+func (q *ChannelledQueueWithLimit[T]) tryRemoveFirst(op string) (t T, err error) {
+	for {
+		if isClosed(q.closedNow) {
+			return t, q.closedError(op)
+		}
+		c := q.c.Load()
+		select {
+		case t, ok := <-*c:
+			if !ok && q.c.Load() != c {
+				continue
+			}
+			return q.received(t, ok, op)
+		default:
+			return t, &QueueError{Op: op, Capacity: q.MaxSize(), Err: ErrQueueEmpty}
+		}
 	}
 }
 
+// removed reports n removed elements to the observer unless err is set, and returns err.
+func (q *ChannelledQueueWithLimit[T]) removed(n int, err error) error {
+	if q.observer != nil && err == nil && n > 0 {
+		q.observer.OnDequeue(uint(n), q.Size())
+	}
+	return err
+}
+
+// MaxSize returns the capacity of the current channel, it changes once SetMaxSize has replaced the channel.
 func (q *ChannelledQueueWithLimit[T]) MaxSize() uint {
-	return uint(cap(q.c))
+	return uint(cap(*q.c.Load()))
 }
 
 func (q *ChannelledQueueWithLimit[T]) Size() uint {
-	return uint(len(q.c))
+	return uint(len(*q.c.Load()))
+}
+
+// SetMaxSize replaces the channel with one of the given capacity and moves the elements to it. Blocked producers
+// retry on the new channel, so growing wakes them up right away. Shrinking below the current size stops new adds
+// until consumers have removed enough elements, or until ctx is done, in which case nothing changes.
+func (q *ChannelledQueueWithLimit[T]) SetMaxSize(ctx context.Context, maxSize uint) error {
+	q.resizeLock.Lock()
+	defer q.resizeLock.Unlock()
+	defer q.resume()
+	for {
+		if q.Size() > maxSize {
+			q.pause()
+			err := await(ctx, q.notFull, 0, func() bool {
+				return q.Size() <= maxSize || isClosed(q.closing)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		q.lockProducers()
+		if isClosed(q.closing) {
+			q.lock.Unlock()
+			return q.closedError("SetMaxSize")
+		}
+		old := q.c.Load()
+		// producers which have not been paused may have added elements in the meantime
+		if uint(len(*old)) <= maxSize {
+			c := make(chan T, maxSize)
+		moving:
+			for {
+				select {
+				case t := <-*old:
+					c <- t
+				default:
+					break moving
+				}
+			}
+			q.c.Store(&c)
+			close(*old)
+			q.lock.Unlock()
+			return nil
+		}
+		q.lock.Unlock()
+	}
+}
+
+// pause makes adds wait until resume is called, including the ones which are blocked on a send.
+func (q *ChannelledQueueWithLimit[T]) pause() {
+	if q.paused.Load() != nil {
+		return
+	}
+	paused := make(chan struct{})
+	q.paused.Store(&paused)
+	q.lockProducers()
+	q.lock.Unlock()
+}
+
+func (q *ChannelledQueueWithLimit[T]) resume() {
+	if paused := q.paused.Swap(nil); paused != nil {
+		close(*paused)
+	}
+}
+
+// lockProducers takes the write lock. Producers blocked on a send hold the read lock, so they are kicked first.
+// Only SetMaxSize may call it.
+func (q *ChannelledQueueWithLimit[T]) lockProducers() {
+	close(q.kick)
+	q.lock.Lock()
+	q.kick = make(chan struct{})
+}
+
+// SetObserver sets the observer notified about events of the queue. It must be set before the queue is shared
+// between goroutines.
+func (q *ChannelledQueueWithLimit[T]) SetObserver(observer Observer) {
+	q.observer = observer
+}
+
+// Close stops accepting new elements, blocked and later adds fail with ErrClosed. Consumers still receive
+// the remaining elements, and then fail with ErrClosed too.
+func (q *ChannelledQueueWithLimit[T]) Close() {
+	q.closeOnce.Do(func() {
+		close(q.closing)
+		q.notFull.notify()
+		q.lock.Lock()
+		close(*q.c.Load())
+		close(q.closed)
+		q.lock.Unlock()
+		q.checkDrained()
+	})
+}
+
+// CloseNow closes the queue and makes all blocked and later operations fail with ErrClosed, even if there are
+// elements left. They can still be removed with Drain.
+func (q *ChannelledQueueWithLimit[T]) CloseNow() {
+	q.nowOnce.Do(func() {
+		close(q.closedNow)
+	})
+	q.Close()
+	q.closeDone()
+}
+
+// Done returns a channel which is closed once the queue has been closed and RemoveFirst will not return any more
+// elements.
+func (q *ChannelledQueueWithLimit[T]) Done() <-chan struct{} {
+	return q.done
+}
+
+// Drain removes elements until the queue is closed and empty or until ctx is done, and returns them.
+func (q *ChannelledQueueWithLimit[T]) Drain(ctx context.Context) []T {
+	var ts []T
+	for {
+		c := q.c.Load()
+		select {
+		case t, ok := <-*c:
+			if !ok {
+				if q.c.Load() != c {
+					continue
+				}
+				q.closeDone()
+				_ = q.removed(len(ts), nil)
+				return ts
+			}
+			ts = append(ts, t)
+			q.notFull.notify()
+		case <-ctx.Done():
+			_ = q.removed(len(ts), nil)
+			return ts
+		}
+	}
+}
+
+func (q *ChannelledQueueWithLimit[T]) received(t T, ok bool, op string) (T, error) {
+	if !ok {
+		q.closeDone()
+		return t, q.closedError(op)
+	}
+	q.notFull.notify()
+	q.checkDrained()
+	return t, nil
+}
+
+// checkDrained closes the done channel if the queue has been closed and the last element has been removed.
+func (q *ChannelledQueueWithLimit[T]) checkDrained() {
+	if isClosed(q.closed) && q.Size() == 0 {
+		q.closeDone()
+	}
+}
+
+func (q *ChannelledQueueWithLimit[T]) closeDone() {
+	q.doneOnce.Do(func() {
+		close(q.done)
+	})
+}
+
+func (q *ChannelledQueueWithLimit[T]) closedError(op string) error {
+	return &QueueError{Op: op, Capacity: q.MaxSize(), Err: ErrClosed}
+}
+
+func isClosed(c <-chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
 }
@@ -0,0 +1,75 @@
+package collections
+
+import (
+	"errors"
+	"iter"
+)
+
+// CircularBuffer a queue of fixed capacity built on the ring of an ArrayQueue. Adding an element to a full buffer
+// never blocks or fails, it overwrites the first element instead. This implementation is not threadsafe.
+type CircularBuffer[T any] struct {
+	queue    *ArrayQueue[T]
+	capacity uint
+	onDrop   func(T)
+	dropped  uint64
+}
+
+func NewCircularBuffer[T any](capacity uint) (*CircularBuffer[T], error) {
+	if capacity == 0 {
+		return nil, errors.New("capacity must be positive")
+	}
+	return &CircularBuffer[T]{
+		queue:    NewArrayQueueWithInitialCapacity[T](capacity),
+		capacity: capacity,
+	}, nil
+}
+
+// AddLast adds an element to the end of the buffer. If the buffer is full, the first element is overwritten and
+// passed to the function set with SetOnDrop.
+func (b *CircularBuffer[T]) AddLast(t T) {
+	if b.queue.Size() < b.capacity {
+		b.queue.AddLast(t)
+		return
+	}
+	x := b.queue.overwriteFirst(t)
+	b.dropped++
+	if b.onDrop != nil {
+		b.onDrop(x)
+	}
+}
+
+func (b *CircularBuffer[T]) RemoveFirst() (T, error) {
+	return b.queue.RemoveFirst()
+}
+
+func (b *CircularBuffer[T]) PeekFirst() (T, error) {
+	return b.queue.PeekFirst()
+}
+
+func (b *CircularBuffer[T]) PeekLast() (T, error) {
+	return b.queue.PeekLast()
+}
+
+func (b *CircularBuffer[T]) Size() uint {
+	return b.queue.Size()
+}
+
+// Capacity returns the max number of elements the buffer keeps.
+func (b *CircularBuffer[T]) Capacity() uint {
+	return b.capacity
+}
+
+// All returns an iterator over elements of the buffer from the first to the last one without removing them.
+func (b *CircularBuffer[T]) All() iter.Seq[T] {
+	return b.queue.All()
+}
+
+// SetOnDrop sets a function called with every overwritten element.
+func (b *CircularBuffer[T]) SetOnDrop(onDrop func(T)) {
+	b.onDrop = onDrop
+}
+
+// Dropped returns the number of elements overwritten so far.
+func (b *CircularBuffer[T]) Dropped() uint64 {
+	return b.dropped
+}
@@ -0,0 +1,62 @@
+package collections
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCircularBuffer_Overwrite(t *testing.T) {
+	b, err := NewCircularBuffer[int](3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dropped []int
+	b.SetOnDrop(func(x int) {
+		dropped = append(dropped, x)
+	})
+	tests := []struct {
+		added    int
+		expected []int
+		dropped  []int
+	}{
+		{0, []int{0}, nil},
+		{1, []int{0, 1}, nil},
+		{2, []int{0, 1, 2}, nil},
+		{3, []int{1, 2, 3}, []int{0}},
+		{4, []int{2, 3, 4}, []int{0, 1}},
+	}
+	for _, test := range tests {
+		b.AddLast(test.added)
+		if elements := slices.Collect(b.All()); !slices.Equal(elements, test.expected) {
+			t.Fatalf("expected %v got %v", test.expected, elements)
+		}
+		if !slices.Equal(dropped, test.dropped) {
+			t.Fatalf("expected %v got %v", test.dropped, dropped)
+		}
+	}
+	if b.Dropped() != 2 {
+		t.Fatalf("expected %d got %d", 2, b.Dropped())
+	}
+	if x, _ := b.PeekLast(); x != 4 {
+		t.Fatalf("expected %d got %d", 4, x)
+	}
+
+	// after removing an element there is space again
+	if x, _ := b.RemoveFirst(); x != 2 {
+		t.Fatalf("expected %d got %d", 2, x)
+	}
+	b.AddLast(5)
+	b.AddLast(6)
+	if elements := slices.Collect(b.All()); !slices.Equal(elements, []int{4, 5, 6}) {
+		t.Fatalf("expected %v got %v", []int{4, 5, 6}, elements)
+	}
+	if b.Dropped() != 3 {
+		t.Fatalf("expected %d got %d", 3, b.Dropped())
+	}
+}
+
+func TestCircularBuffer_ZeroCapacity(t *testing.T) {
+	if _, err := NewCircularBuffer[int](0); err == nil {
+		t.Fatalf("expected error creating a buffer without capacity")
+	}
+}
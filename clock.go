@@ -0,0 +1,43 @@
+package collections
+
+import (
+	"time"
+)
+
+// Clock source of the current time and of timers, so that time dependent collections such as DelayQueue can be
+// tested without real sleeping.
+type Clock interface {
+	Now() time.Time
+	NewTimer(time.Duration) Timer
+}
+
+// Timer a single event in the future, created by a Clock.
+type Timer interface {
+	// C returns the channel on which the time is delivered when the timer fires.
+	C() <-chan time.Time
+	// Stop prevents the timer from firing. Returns false if it has already fired or been stopped.
+	Stop() bool
+}
+
+// SystemClock a Clock using the time package.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t systemTimer) Stop() bool {
+	return t.timer.Stop()
+}
@@ -0,0 +1,26 @@
+package collections
+
+import (
+	"context"
+)
+
+// ClosableQueueWithLimit QueueWithLimit which can be shut down, waking up goroutines blocked on it.
+type ClosableQueueWithLimit[T any] interface {
+	QueueWithLimit[T]
+
+	// Close stops accepting new elements: blocked and later adds fail with ErrClosed. Consumers still receive
+	// the remaining elements and then fail with ErrClosed too.
+	Close()
+
+	// CloseNow closes the queue and makes all blocked and later operations fail with ErrClosed immediately, even if
+	// some elements are left. They can still be removed with Drain.
+	CloseNow()
+
+	// Done returns a channel which is closed once the queue has been closed and RemoveFirst will not return any more
+	// elements.
+	Done() <-chan struct{}
+
+	// Drain removes elements until the queue is closed and empty or until the given context is done, and returns
+	// them.
+	Drain(context.Context) []T
+}
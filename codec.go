@@ -0,0 +1,42 @@
+package collections
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec converts elements to bytes and back, e.g. to store them in a DurableQueue.
+type Codec[T any] interface {
+	Encode(T) ([]byte, error)
+	Decode([]byte) (T, error)
+}
+
+// GobCodec a Codec using encoding/gob. Every element is encoded with its own type description, so it is simple
+// but not the most compact.
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Encode(t T) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(t); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (GobCodec[T]) Decode(data []byte) (t T, err error) {
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&t)
+	return t, err
+}
+
+// JSONCodec a Codec using encoding/json.
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Encode(t T) ([]byte, error) {
+	return json.Marshal(t)
+}
+
+func (JSONCodec[T]) Decode(data []byte) (t T, err error) {
+	err = json.Unmarshal(data, &t)
+	return t, err
+}
@@ -0,0 +1,187 @@
+package collectionstest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/viger-pro/go-collections"
+)
+
+// RunClosableQueueWithLimitSuite runs tests checking that queues returned by newQueue close and drain according to
+// the ClosableQueueWithLimit contract. newQueue is called once per test and must return an empty open queue able to
+// store maxSize elements.
+func RunClosableQueueWithLimitSuite(t *testing.T, newQueue func(maxSize uint) (collections.ClosableQueueWithLimit[uint], error)) {
+	create := func(t *testing.T, maxSize uint) collections.ClosableQueueWithLimit[uint] {
+		queue, err := newQueue(maxSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return queue
+	}
+	t.Run("Close", func(t *testing.T) {
+		testClosableQueueClose(t, create(t, 10))
+	})
+	t.Run("CloseWakesProducers", func(t *testing.T) {
+		testClosableQueueCloseWakesProducers(t, create(t, 1))
+	})
+	t.Run("CloseWakesConsumers", func(t *testing.T) {
+		testClosableQueueCloseWakesConsumers(t, create(t, 1))
+	})
+	t.Run("CloseNow", func(t *testing.T) {
+		testClosableQueueCloseNow(t, create(t, 10))
+	})
+	t.Run("Drain", func(t *testing.T) {
+		testClosableQueueDrain(t, create(t, 10))
+	})
+	t.Run("DrainTimeout", func(t *testing.T) {
+		testClosableQueueDrainTimeout(t, create(t, 10))
+	})
+}
+
+func expectClosed(t *testing.T, err error) {
+	t.Helper()
+	if !errors.Is(err, collections.ErrClosed) {
+		t.Fatalf("expected queue is closed error, got %v", err)
+	}
+}
+
+func expectDone(t *testing.T, queue collections.ClosableQueueWithLimit[uint], done bool) {
+	t.Helper()
+	select {
+	case <-queue.Done():
+		if !done {
+			t.Fatalf("expected Done not to be closed yet")
+		}
+	default:
+		if done {
+			t.Fatalf("expected Done to be closed")
+		}
+	}
+}
+
+func testClosableQueueClose(t *testing.T, queue collections.ClosableQueueWithLimit[uint]) {
+	ctx := context.Background()
+	for i := uint(0); i < 2; i++ {
+		if err := queue.AddLast(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	queue.Close()
+	queue.Close()
+	expectClosed(t, queue.TryAddLast(2))
+	expectClosed(t, queue.AddLast(ctx, 2))
+	expectDone(t, queue, false)
+	for i := uint(0); i < 2; i++ {
+		x, err := queue.RemoveFirst(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != i {
+			t.Fatalf("expected %d got %d", i, x)
+		}
+	}
+	_, err := queue.RemoveFirst(ctx)
+	expectClosed(t, err)
+	_, err = queue.TryRemoveFirst()
+	expectClosed(t, err)
+	expectDone(t, queue, true)
+}
+
+func testClosableQueueCloseWakesProducers(t *testing.T, queue collections.ClosableQueueWithLimit[uint]) {
+	ctx := context.Background()
+	if err := queue.AddLast(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error)
+	go func() {
+		errs <- queue.AddLast(ctx, 1)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	queue.Close()
+	expectClosed(t, <-errs)
+	x, err := queue.RemoveFirst(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x != 0 {
+		t.Fatalf("expected %d got %d", 0, x)
+	}
+}
+
+func testClosableQueueCloseWakesConsumers(t *testing.T, queue collections.ClosableQueueWithLimit[uint]) {
+	errs := make(chan error)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := queue.RemoveFirst(context.Background())
+			errs <- err
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	queue.Close()
+	for i := 0; i < 2; i++ {
+		expectClosed(t, <-errs)
+	}
+	expectDone(t, queue, true)
+}
+
+func testClosableQueueCloseNow(t *testing.T, queue collections.ClosableQueueWithLimit[uint]) {
+	ctx := context.Background()
+	for i := uint(0); i < 2; i++ {
+		if err := queue.AddLast(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	queue.CloseNow()
+	expectDone(t, queue, true)
+	_, err := queue.RemoveFirst(ctx)
+	expectClosed(t, err)
+	_, err = queue.TryRemoveFirst()
+	expectClosed(t, err)
+	expectClosed(t, queue.TryAddLast(2))
+	if rest := queue.Drain(ctx); !slices.Equal(rest, []uint{0, 1}) {
+		t.Fatalf("expected %v got %v", []uint{0, 1}, rest)
+	}
+}
+
+func testClosableQueueDrain(t *testing.T, queue collections.ClosableQueueWithLimit[uint]) {
+	const n = 100
+	go func() {
+		defer queue.Close()
+		for i := uint(0); i < n; i++ {
+			if err := queue.AddLast(context.Background(), i); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	rest := queue.Drain(context.Background())
+	if len(rest) != n {
+		t.Fatalf("expected %d got %d", n, len(rest))
+	}
+	for i, x := range rest {
+		if x != uint(i) {
+			t.Fatalf("expected %d got %d", i, x)
+		}
+	}
+	expectDone(t, queue, true)
+}
+
+func testClosableQueueDrainTimeout(t *testing.T, queue collections.ClosableQueueWithLimit[uint]) {
+	for i := uint(0); i < 3; i++ {
+		if err := queue.TryAddLast(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if rest := queue.Drain(ctx); !slices.Equal(rest, []uint{0, 1, 2}) {
+		t.Fatalf("expected %v got %v", []uint{0, 1, 2}, rest)
+	}
+	expectDone(t, queue, false)
+	if err := queue.TryAddLast(3); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,119 @@
+package collectionstest
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/viger-pro/go-collections"
+)
+
+// Heap the part of collections.Heap the heap suite relies on, so that wrappers can be tested as well.
+type Heap[T any] interface {
+	Add(T)
+	Remove() (T, error)
+	GetFirst() (T, error)
+	Size() int
+	IsEmpty() bool
+}
+
+// RunHeapSuite runs tests checking that heaps returned by newHeap return elements in ascending order.
+// newHeap is called once per test and must return an empty heap.
+func RunHeapSuite(t *testing.T, newHeap func(initialCapacity int) Heap[int]) {
+	for _, n := range []int{0, 1, 10, 1000} {
+		t.Run(fmt.Sprintf("Ordered %d", n), func(t *testing.T) {
+			testHeapSorts(t, newHeap(n/2), orderedInts(n))
+		})
+		t.Run(fmt.Sprintf("Reversed %d", n), func(t *testing.T) {
+			input := orderedInts(n)
+			slices.Reverse(input)
+			testHeapSorts(t, newHeap(n/2), input)
+		})
+		t.Run(fmt.Sprintf("Random %d", n), func(t *testing.T) {
+			input := orderedInts(n)
+			rand.Shuffle(n, func(i, j int) {
+				input[i], input[j] = input[j], input[i]
+			})
+			testHeapSorts(t, newHeap(n/2), input)
+		})
+	}
+	t.Run("Duplicated", func(t *testing.T) {
+		input := make([]int, 1000)
+		for i := range input {
+			input[i] = rand.Intn(10)
+		}
+		testHeapSorts(t, newHeap(0), input)
+	})
+	t.Run("Interleaved", func(t *testing.T) {
+		testHeapInterleaved(t, newHeap(0))
+	})
+}
+
+func testHeapSorts(t *testing.T, heap Heap[int], input []int) {
+	expected := slices.Clone(input)
+	slices.Sort(expected)
+	for i, x := range input {
+		heap.Add(x)
+		if heap.Size() != i+1 {
+			t.Fatalf("expected size %d got %d, input: %v", i+1, heap.Size(), input)
+		}
+		if first, err := heap.GetFirst(); err != nil || first != slices.Min(input[:i+1]) {
+			t.Fatalf("expected first element %d got %d, %v, input: %v", slices.Min(input[:i+1]), first, err, input)
+		}
+	}
+	for i, x := range expected {
+		y, err := heap.Remove()
+		if err != nil {
+			t.Fatalf("error while removing from heap: %v, input: %v", err, input)
+		}
+		if x != y {
+			t.Fatalf("expected removed element to be %d, got %d, input: %v", x, y, input)
+		}
+		if heap.Size() != len(input)-i-1 {
+			t.Fatalf("expected size %d got %d, input: %v", len(input)-i-1, heap.Size(), input)
+		}
+	}
+	if !heap.IsEmpty() {
+		t.Fatalf("expected heap to be empty, input: %v", input)
+	}
+	if x, err := heap.Remove(); !errors.Is(err, collections.ErrEmptyHeap) {
+		t.Fatalf("expected heap is empty error from Remove, got %d, %v", x, err)
+	}
+	if x, err := heap.GetFirst(); !errors.Is(err, collections.ErrEmptyHeap) {
+		t.Fatalf("expected heap is empty error from GetFirst, got %d, %v", x, err)
+	}
+}
+
+func testHeapInterleaved(t *testing.T, heap Heap[int]) {
+	var expected []int
+	for i := 0; i < 10_000; i++ {
+		if rand.Intn(3) > 0 {
+			x := rand.Intn(1000)
+			heap.Add(x)
+			expected = append(expected, x)
+			continue
+		}
+		x, err := heap.Remove()
+		if len(expected) == 0 {
+			if !errors.Is(err, collections.ErrEmptyHeap) {
+				t.Fatalf("expected heap is empty error, got %d, %v", x, err)
+			}
+			continue
+		}
+		minimum := slices.Min(expected)
+		if err != nil || x != minimum {
+			t.Fatalf("expected %d got %d, %v", minimum, x, err)
+		}
+		expected = slices.Delete(expected, slices.Index(expected, minimum), slices.Index(expected, minimum)+1)
+	}
+}
+
+func orderedInts(n int) []int {
+	result := make([]int, n)
+	for i := range result {
+		result[i] = i
+	}
+	return result
+}
@@ -0,0 +1,260 @@
+package collectionstest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/viger-pro/go-collections"
+)
+
+// OperationKind method of QueueWithLimit an Operation invoked.
+type OperationKind int
+
+const (
+	AddLast OperationKind = iota
+	TryAddLast
+	RemoveFirst
+	TryRemoveFirst
+)
+
+func (k OperationKind) String() string {
+	switch k {
+	case AddLast:
+		return "AddLast"
+	case TryAddLast:
+		return "TryAddLast"
+	case RemoveFirst:
+		return "RemoveFirst"
+	case TryRemoveFirst:
+		return "TryRemoveFirst"
+	}
+	return fmt.Sprintf("OperationKind(%d)", int(k))
+}
+
+// Operation a completed call recorded in a history. Call and Return are logical timestamps taken right before the
+// call and right after it returned, so an operation happened before another one if its Return is smaller than the
+// other's Call.
+type Operation struct {
+	Kind OperationKind
+	// Value element passed to an add or returned by a successful remove.
+	Value  uint
+	Err    error
+	Call   int64
+	Return int64
+}
+
+func (o Operation) String() string {
+	return fmt.Sprintf("[%d, %d] %v(%d) %v", o.Call, o.Return, o.Kind, o.Value, o.Err)
+}
+
+// Recorder records operations invoked on a queue from many goroutines.
+type Recorder struct {
+	clock   atomic.Int64
+	lock    sync.Mutex
+	history []Operation
+}
+
+// Record invokes call, which performs an operation of the given kind, and appends it to the history. For adds
+// value is the added element, for removes the value returned by call is recorded instead.
+func (r *Recorder) Record(kind OperationKind, value uint, call func() (uint, error)) {
+	operation := Operation{Kind: kind, Call: r.clock.Add(1)}
+	result, err := call()
+	operation.Return = r.clock.Add(1)
+	operation.Err = err
+	operation.Value = value
+	if kind == RemoveFirst || kind == TryRemoveFirst {
+		operation.Value = result
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.history = append(r.history, operation)
+}
+
+// History returns operations recorded so far.
+func (r *Recorder) History() []Operation {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return slices.Clone(r.history)
+}
+
+// CheckQueueWithLimitHistory checks whether the history is linearizable with respect to a sequential FIFO queue
+// storing at most maxSize elements, using the Wing & Gong search with memoization of visited states. Operations
+// that failed with a context error are assumed to have had no effect. Any other error has to be ErrQueueFull
+// returned by TryAddLast or ErrQueueEmpty returned by TryRemoveFirst, AddLast and RemoveFirst block instead. Returns
+// nil if the history is linearizable.
+func CheckQueueWithLimitHistory(history []Operation, maxSize uint) error {
+	var operations []Operation
+	for _, operation := range history {
+		switch {
+		case operation.Err == nil,
+			operation.Kind == TryAddLast && errors.Is(operation.Err, collections.ErrQueueFull),
+			operation.Kind == TryRemoveFirst && errors.Is(operation.Err, collections.ErrQueueEmpty):
+			operations = append(operations, operation)
+		case errors.Is(operation.Err, context.Canceled), errors.Is(operation.Err, context.DeadlineExceeded):
+		default:
+			return fmt.Errorf("unexpected error in %v", operation)
+		}
+	}
+	slices.SortFunc(operations, func(o1, o2 Operation) int {
+		return int(o1.Call - o2.Call)
+	})
+	checker := &linearizabilityChecker{
+		operations: operations,
+		maxSize:    maxSize,
+		linearized: make([]bool, len(operations)),
+		visited:    make(map[string]bool),
+	}
+	if !checker.search(nil, 0) {
+		return fmt.Errorf("history is not linearizable:\n%s", formatHistory(operations))
+	}
+	return nil
+}
+
+type linearizabilityChecker struct {
+	operations []Operation
+	maxSize    uint
+	linearized []bool
+	visited    map[string]bool
+}
+
+func (c *linearizabilityChecker) search(state []uint, done int) bool {
+	if done == len(c.operations) {
+		return true
+	}
+	// only operations invoked before the first pending operation returned may be linearized next
+	firstReturn := int64(-1)
+	for i, operation := range c.operations {
+		if !c.linearized[i] && (firstReturn < 0 || operation.Return < firstReturn) {
+			firstReturn = operation.Return
+		}
+	}
+	for i, operation := range c.operations {
+		if operation.Call > firstReturn {
+			break
+		}
+		if c.linearized[i] {
+			continue
+		}
+		next, ok := c.apply(state, operation)
+		if !ok {
+			continue
+		}
+		c.linearized[i] = true
+		key := c.key(next)
+		if !c.visited[key] {
+			c.visited[key] = true
+			if c.search(next, done+1) {
+				return true
+			}
+		}
+		c.linearized[i] = false
+	}
+	return false
+}
+
+// apply returns the state of the sequential queue after the operation, or false if the operation could not have
+// returned what it did in the given state.
+func (c *linearizabilityChecker) apply(state []uint, operation Operation) ([]uint, bool) {
+	switch operation.Kind {
+	case AddLast, TryAddLast:
+		if operation.Err != nil {
+			return state, uint(len(state)) == c.maxSize
+		}
+		if uint(len(state)) == c.maxSize {
+			return nil, false
+		}
+		return append(slices.Clip(state), operation.Value), true
+	case RemoveFirst, TryRemoveFirst:
+		if operation.Err != nil {
+			return state, len(state) == 0
+		}
+		if len(state) == 0 || state[0] != operation.Value {
+			return nil, false
+		}
+		return state[1:], true
+	}
+	return nil, false
+}
+
+func (c *linearizabilityChecker) key(state []uint) string {
+	var builder strings.Builder
+	for _, linearized := range c.linearized {
+		if linearized {
+			builder.WriteByte('1')
+		} else {
+			builder.WriteByte('0')
+		}
+	}
+	for _, value := range state {
+		builder.WriteString(fmt.Sprintf(",%d", value))
+	}
+	return builder.String()
+}
+
+func formatHistory(operations []Operation) string {
+	var builder strings.Builder
+	for _, operation := range operations {
+		builder.WriteString(operation.String())
+		builder.WriteByte('\n')
+	}
+	return builder.String()
+}
+
+// RunLinearizabilitySuite records histories of random operations invoked on queues returned by newQueue from many
+// goroutines and checks them with CheckQueueWithLimitHistory.
+func RunLinearizabilitySuite(t *testing.T, newQueue func(maxSize uint) (collections.QueueWithLimit[uint], error)) {
+	var rounds = 50
+	var goroutines = 4
+	var operationsPerGoroutine = 15
+	for round := 0; round < rounds; round++ {
+		maxSize := uint(1 + rand.Intn(3))
+		queue, err := newQueue(maxSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder := &Recorder{}
+		wg := sync.WaitGroup{}
+		for g := 0; g < goroutines; g++ {
+			wg.Go(func() {
+				for i := 0; i < operationsPerGoroutine; i++ {
+					recordRandomOperation(recorder, queue, uint(g*operationsPerGoroutine+i))
+				}
+			})
+		}
+		wg.Wait()
+		if err := CheckQueueWithLimitHistory(recorder.History(), maxSize); err != nil {
+			t.Fatalf("round %d, max size %d: %v", round, maxSize, err)
+		}
+	}
+}
+
+func recordRandomOperation(recorder *Recorder, queue collections.QueueWithLimit[uint], value uint) {
+	switch kind := OperationKind(rand.Intn(4)); kind {
+	case AddLast:
+		recorder.Record(kind, value, func() (uint, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+			defer cancel()
+			return 0, queue.AddLast(ctx, value)
+		})
+	case TryAddLast:
+		recorder.Record(kind, value, func() (uint, error) {
+			return 0, queue.TryAddLast(value)
+		})
+	case RemoveFirst:
+		recorder.Record(kind, 0, func() (uint, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+			defer cancel()
+			return queue.RemoveFirst(ctx)
+		})
+	case TryRemoveFirst:
+		recorder.Record(kind, 0, queue.TryRemoveFirst)
+	}
+}
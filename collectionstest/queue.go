@@ -0,0 +1,113 @@
+// Package collectionstest provides test suites checking that implementations of the collections interfaces, as well
+// as wrappers around them, fulfil their contracts.
+package collectionstest
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/viger-pro/go-collections"
+)
+
+// RunQueueSuite runs tests checking that queues returned by newQueue behave as unbounded FIFO queues.
+// newQueue is called once per test and must return an empty queue.
+func RunQueueSuite(t *testing.T, newQueue func() collections.Queue[int]) {
+	t.Run("HappyPath", func(t *testing.T) {
+		testQueueHappyPath(t, newQueue())
+	})
+	t.Run("Peek", func(t *testing.T) {
+		testQueuePeek(t, newQueue())
+	})
+	t.Run("Empty", func(t *testing.T) {
+		testQueueEmpty(t, newQueue())
+	})
+	t.Run("Randomized", func(t *testing.T) {
+		testQueueRandomized(t, newQueue())
+	})
+}
+
+func testQueueHappyPath(t *testing.T, queue collections.Queue[int]) {
+	var n = 1000
+	for i := 0; i < n; i++ {
+		queue.AddLast(i)
+		if queue.Size() != uint(i+1) {
+			t.Fatalf("expected size %d got %d", i+1, queue.Size())
+		}
+	}
+	for i := 0; i < n; i++ {
+		x, err := queue.RemoveFirst()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != i {
+			t.Fatalf("expected %d got %d", i, x)
+		}
+		if queue.Size() != uint(n-i-1) {
+			t.Fatalf("expected size %d got %d", n-i-1, queue.Size())
+		}
+	}
+}
+
+func testQueuePeek(t *testing.T, queue collections.Queue[int]) {
+	for i := 0; i < 10; i++ {
+		queue.AddLast(i)
+		first, err := queue.PeekFirst()
+		if err != nil || first != 0 {
+			t.Fatalf("expected first element %d got %d, %v", 0, first, err)
+		}
+		last, err := queue.PeekLast()
+		if err != nil || last != i {
+			t.Fatalf("expected last element %d got %d, %v", i, last, err)
+		}
+	}
+	if queue.Size() != 10 {
+		t.Fatalf("expected peeking not to change size, got %d", queue.Size())
+	}
+}
+
+func testQueueEmpty(t *testing.T, queue collections.Queue[int]) {
+	if queue.Size() != 0 {
+		t.Fatalf("expected a new queue to be empty, got size %d", queue.Size())
+	}
+	for i := 0; i < 2; i++ {
+		if x, err := queue.RemoveFirst(); !errors.Is(err, collections.ErrQueueEmpty) {
+			t.Fatalf("expected queue is empty error from RemoveFirst, got %d, %v", x, err)
+		}
+		if x, err := queue.PeekFirst(); !errors.Is(err, collections.ErrQueueEmpty) {
+			t.Fatalf("expected queue is empty error from PeekFirst, got %d, %v", x, err)
+		}
+		if x, err := queue.PeekLast(); !errors.Is(err, collections.ErrQueueEmpty) {
+			t.Fatalf("expected queue is empty error from PeekLast, got %d, %v", x, err)
+		}
+		queue.AddLast(i)
+		if _, err := queue.RemoveFirst(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func testQueueRandomized(t *testing.T, queue collections.Queue[int]) {
+	var expected []int
+	for i := 0; i < 10_000; i++ {
+		if rand.Intn(3) > 0 {
+			queue.AddLast(i)
+			expected = append(expected, i)
+		} else {
+			x, err := queue.RemoveFirst()
+			if len(expected) == 0 {
+				if !errors.Is(err, collections.ErrQueueEmpty) {
+					t.Fatalf("expected queue is empty error, got %d, %v", x, err)
+				}
+				continue
+			}
+			if err != nil || x != expected[0] {
+				t.Fatalf("expected %d got %d, %v", expected[0], x, err)
+			}
+			expected = expected[1:]
+		}
+		if queue.Size() != uint(len(expected)) {
+			t.Fatalf("expected size %d got %d", len(expected), queue.Size())
+		}
+	}
+}
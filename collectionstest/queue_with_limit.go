@@ -0,0 +1,257 @@
+package collectionstest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/viger-pro/go-collections"
+)
+
+// RunQueueWithLimitSuite runs tests checking that queues returned by newQueue fulfil the QueueWithLimit contract,
+// including blocking, context cancellation and many concurrent producers and consumers. newQueue is called once per
+// test and must return an empty queue able to store maxSize elements.
+func RunQueueWithLimitSuite(t *testing.T, newQueue func(maxSize uint) (collections.QueueWithLimit[uint], error)) {
+	create := func(t *testing.T, maxSize uint) collections.QueueWithLimit[uint] {
+		queue, err := newQueue(maxSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if queue.MaxSize() != maxSize {
+			t.Fatalf("expected max size %d got %d", maxSize, queue.MaxSize())
+		}
+		if queue.Size() != 0 {
+			t.Fatalf("expected a new queue to be empty, got size %d", queue.Size())
+		}
+		return queue
+	}
+	t.Run("HappyPath", func(t *testing.T) {
+		testQueueWithLimitHappyPath(t, create(t, 10))
+	})
+	t.Run("TryAddLast", func(t *testing.T) {
+		testQueueWithLimitTryAddLast(t, create(t, 10))
+	})
+	t.Run("TryRemoveFirst", func(t *testing.T) {
+		testQueueWithLimitTryRemoveFirst(t, create(t, 10))
+	})
+	t.Run("AddLastTimeout", func(t *testing.T) {
+		testQueueWithLimitAddLastTimeout(t, create(t, 2))
+	})
+	t.Run("RemoveFirstCancel", func(t *testing.T) {
+		testQueueWithLimitRemoveFirstCancel(t, create(t, 2))
+	})
+	t.Run("Blocking", func(t *testing.T) {
+		testQueueWithLimitBlocking(t, create(t, 10))
+	})
+	t.Run("Randomized", func(t *testing.T) {
+		testQueueWithLimitRandomized(t, create(t, 100))
+	})
+	t.Run("Linearizable", func(t *testing.T) {
+		RunLinearizabilitySuite(t, newQueue)
+	})
+}
+
+func testQueueWithLimitHappyPath(t *testing.T, queue collections.QueueWithLimit[uint]) {
+	ctx := context.Background()
+	for round := 0; round < 3; round++ {
+		var i uint
+		for i = 0; i < queue.MaxSize(); i++ {
+			if err := queue.AddLast(ctx, i); err != nil {
+				t.Fatal(err)
+			}
+			if queue.Size() != i+1 {
+				t.Fatalf("expected size %d got %d", i+1, queue.Size())
+			}
+		}
+		for i = 0; i < queue.MaxSize(); i++ {
+			x, err := queue.RemoveFirst(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if x != i {
+				t.Fatalf("expected %d got %d", i, x)
+			}
+		}
+	}
+}
+
+func testQueueWithLimitTryAddLast(t *testing.T, queue collections.QueueWithLimit[uint]) {
+	var i uint
+	for i = 0; i < queue.MaxSize(); i++ {
+		if err := queue.TryAddLast(i); err != nil {
+			t.Fatalf("error when adding %dth element: %v", i, err)
+		}
+	}
+	if err := queue.TryAddLast(queue.MaxSize()); !errors.Is(err, collections.ErrQueueFull) {
+		t.Fatalf("expected queue is full error, got %v", err)
+	}
+	if queue.Size() != queue.MaxSize() {
+		t.Fatalf("expected size %d got %d", queue.MaxSize(), queue.Size())
+	}
+	for i = 0; i < queue.MaxSize(); i++ {
+		x, err := queue.TryRemoveFirst()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != i {
+			t.Fatalf("expected %d got %d", i, x)
+		}
+	}
+	if err := queue.TryAddLast(0); err != nil {
+		t.Fatalf("expected element to be added after the queue has been emptied, got %v", err)
+	}
+}
+
+func testQueueWithLimitTryRemoveFirst(t *testing.T, queue collections.QueueWithLimit[uint]) {
+	ctx := context.Background()
+	if x, err := queue.TryRemoveFirst(); !errors.Is(err, collections.ErrQueueEmpty) {
+		t.Fatalf("expected queue is empty error, got %d, %v", x, err)
+	}
+	if err := queue.AddLast(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if x, err := queue.TryRemoveFirst(); err != nil || x != 1 {
+		t.Fatalf("expected %d got %d, %v", 1, x, err)
+	}
+	if x, err := queue.TryRemoveFirst(); !errors.Is(err, collections.ErrQueueEmpty) {
+		t.Fatalf("expected queue is empty error, got %d, %v", x, err)
+	}
+}
+
+func testQueueWithLimitAddLastTimeout(t *testing.T, queue collections.QueueWithLimit[uint]) {
+	ctx := context.Background()
+	var i uint
+	for i = 0; i < queue.MaxSize(); i++ {
+		if err := queue.AddLast(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := queue.AddLast(timeout, queue.MaxSize()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded when adding to a full queue, got %v", err)
+	}
+	if queue.Size() != queue.MaxSize() {
+		t.Fatalf("expected size %d got %d", queue.MaxSize(), queue.Size())
+	}
+
+	results := make(chan error, 1)
+	go func() {
+		timeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		results <- queue.AddLast(timeout, queue.MaxSize())
+	}()
+	for i = 0; i <= queue.MaxSize(); i++ {
+		x, err := queue.RemoveFirst(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != i {
+			t.Fatalf("expected %d got %d", i, x)
+		}
+	}
+	if err := <-results; err != nil {
+		t.Fatalf("error when adding to a queue that has been drained: %v", err)
+	}
+}
+
+func testQueueWithLimitRemoveFirstCancel(t *testing.T, queue collections.QueueWithLimit[uint]) {
+	ctx, cancel := context.WithCancel(context.Background())
+	results := make(chan error, 1)
+	go func() {
+		_, err := queue.RemoveFirst(ctx)
+		results <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-results; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled when removing from an empty queue, got %v", err)
+	}
+	if _, err := queue.RemoveFirst(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled when removing from an empty queue, got %v", err)
+	}
+	if err := queue.AddLast(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if x, err := queue.TryRemoveFirst(); err != nil || x != 1 {
+		t.Fatalf("expected element added after cancellation to be removed, got %d, %v", x, err)
+	}
+}
+
+func testQueueWithLimitBlocking(t *testing.T, queue collections.QueueWithLimit[uint]) {
+	var steps uint = 100
+	ctx := context.Background()
+	wg := sync.WaitGroup{}
+	results := make(chan error, steps)
+	var i uint
+	for i = 0; i < steps; i++ {
+		x := i
+		wg.Go(func() {
+			results <- queue.AddLast(ctx, x)
+		})
+	}
+	removed := make([]bool, steps)
+	for i = 0; i < steps; i++ {
+		x, err := queue.RemoveFirst(ctx)
+		if err != nil {
+			t.Fatalf("error while removing %dth element: %v", i, err)
+		}
+		if removed[x] {
+			t.Fatalf("%d has been removed twice", x)
+		}
+		removed[x] = true
+	}
+	wg.Wait()
+	close(results)
+	for err := range results {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func testQueueWithLimitRandomized(t *testing.T, queue collections.QueueWithLimit[uint]) {
+	var steps uint = 10_000
+	ctx := context.Background()
+	type result struct {
+		value uint
+		err   error
+	}
+	added := make(chan result, steps)
+	removed := make(chan result, steps)
+	wg := sync.WaitGroup{}
+	var i uint
+	for i = 0; i < steps; i++ {
+		x := i
+		wg.Go(func() {
+			value, err := queue.RemoveFirst(ctx)
+			removed <- result{value, err}
+		})
+		wg.Go(func() {
+			added <- result{x, queue.AddLast(ctx, x)}
+		})
+	}
+	wg.Wait()
+	close(added)
+	close(removed)
+	if queue.Size() != 0 {
+		t.Fatalf("expected queue to be empty, got size %d", queue.Size())
+	}
+	for r := range added {
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+	}
+	seen := make([]bool, steps)
+	for r := range removed {
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+		if seen[r.value] {
+			t.Fatalf("%d has been removed twice", r.value)
+		}
+		seen[r.value] = true
+	}
+}
@@ -0,0 +1,130 @@
+package collections
+
+import (
+	"iter"
+	"sync/atomic"
+)
+
+type concurrentEntry[T any] struct {
+	value T
+	next  atomic.Pointer[concurrentEntry[T]]
+}
+
+// ConcurrentLinkedQueue an unbounded threadsafe queue based on the lock-free algorithm by Michael and Scott.
+// The list always starts with a dummy entry; removing an element turns its entry into the new dummy, so the last
+// removed value stays referenced until the next removal. None of the operations block.
+type ConcurrentLinkedQueue[T any] struct {
+	_    cacheLinePad
+	head atomic.Pointer[concurrentEntry[T]]
+	_    cacheLinePad
+	tail atomic.Pointer[concurrentEntry[T]]
+	_    cacheLinePad
+	size atomic.Int64
+}
+
+func NewConcurrentLinkedQueue[T any]() *ConcurrentLinkedQueue[T] {
+	q := &ConcurrentLinkedQueue[T]{}
+	dummy := &concurrentEntry[T]{}
+	q.head.Store(dummy)
+	q.tail.Store(dummy)
+	return q
+}
+
+func (q *ConcurrentLinkedQueue[T]) AddLast(value T) {
+	e := &concurrentEntry[T]{value: value}
+	for {
+		tail := q.tail.Load()
+		next := tail.next.Load()
+		if tail != q.tail.Load() {
+			continue
+		}
+		if next != nil {
+			// tail is lagging behind, help the other producer to move it
+			q.tail.CompareAndSwap(tail, next)
+			continue
+		}
+		if tail.next.CompareAndSwap(nil, e) {
+			q.tail.CompareAndSwap(tail, e)
+			q.size.Add(1)
+			return
+		}
+	}
+}
+
+// RemoveFirst removes the first element of the queue. It never blocks, it is the same as TryRemoveFirst.
+func (q *ConcurrentLinkedQueue[T]) RemoveFirst() (T, error) {
+	t, ok := q.tryRemoveFirst()
+	if !ok {
+		return t, &QueueError{Op: "RemoveFirst", Err: ErrQueueEmpty}
+	}
+	return t, nil
+}
+
+func (q *ConcurrentLinkedQueue[T]) TryRemoveFirst() (T, error) {
+	t, ok := q.tryRemoveFirst()
+	if !ok {
+		return t, &QueueError{Op: "TryRemoveFirst", Err: ErrQueueEmpty}
+	}
+	return t, nil
+}
+
+func (q *ConcurrentLinkedQueue[T]) PeekFirst() (T, error) {
+	if next := q.head.Load().next.Load(); next != nil {
+		return next.value, nil
+	}
+	var zero T
+	return zero, &QueueError{Op: "PeekFirst", Err: ErrQueueEmpty}
+}
+
+func (q *ConcurrentLinkedQueue[T]) PeekLast() (T, error) {
+	head := q.head.Load()
+	tail := q.tail.Load()
+	for next := tail.next.Load(); next != nil; next = tail.next.Load() {
+		tail = next
+	}
+	if tail == head {
+		var zero T
+		return zero, &QueueError{Op: "PeekLast", Err: ErrQueueEmpty}
+	}
+	return tail.value, nil
+}
+
+// Size returns an approximate number of elements, as it may change while it is being computed.
+func (q *ConcurrentLinkedQueue[T]) Size() uint {
+	return uint(max(q.size.Load(), 0))
+}
+
+// All returns an iterator over elements of the queue from the first to the last one without removing them.
+// The iterator is weakly consistent: it may or may not reflect changes made concurrently.
+func (q *ConcurrentLinkedQueue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for e := q.head.Load().next.Load(); e != nil; e = e.next.Load() {
+			if !yield(e.value) {
+				return
+			}
+		}
+	}
+}
+
+func (q *ConcurrentLinkedQueue[T]) tryRemoveFirst() (t T, ok bool) {
+	for {
+		head := q.head.Load()
+		tail := q.tail.Load()
+		next := head.next.Load()
+		if head != q.head.Load() {
+			continue
+		}
+		if next == nil {
+			return t, false
+		}
+		if head == tail {
+			// tail is lagging behind, help the producer to move it
+			q.tail.CompareAndSwap(tail, next)
+			continue
+		}
+		if q.head.CompareAndSwap(head, next) {
+			q.size.Add(-1)
+			return next.value, true
+		}
+	}
+}
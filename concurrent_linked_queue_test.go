@@ -0,0 +1,104 @@
+package collections
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentLinkedQueue(t *testing.T) {
+	var queue = NewConcurrentLinkedQueue[int]()
+	if _, err := queue.TryRemoveFirst(); !errors.Is(err, ErrQueueEmpty) {
+		t.Fatalf("expected queue is empty error, got %v", err)
+	}
+	if _, err := queue.PeekLast(); !errors.Is(err, ErrQueueEmpty) {
+		t.Fatalf("expected queue is empty error, got %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		queue.AddLast(i)
+	}
+	if queue.Size() != 10 {
+		t.Fatalf("expected %d got %d", 10, queue.Size())
+	}
+	if all := slices.Collect(queue.All()); !slices.Equal(all, orderedIntArray(10)) {
+		t.Fatalf("expected %v got %v", orderedIntArray(10), all)
+	}
+	if first, err := queue.PeekFirst(); err != nil || first != 0 {
+		t.Fatalf("expected %d got %d, %v", 0, first, err)
+	}
+	if last, err := queue.PeekLast(); err != nil || last != 9 {
+		t.Fatalf("expected %d got %d, %v", 9, last, err)
+	}
+	for i := 0; i < 10; i++ {
+		if x, err := queue.RemoveFirst(); err != nil || x != i {
+			t.Fatalf("expected %d got %d, %v", i, x, err)
+		}
+	}
+	if queue.Size() != 0 {
+		t.Fatalf("expected %d got %d", 0, queue.Size())
+	}
+}
+
+// TestConcurrentLinkedQueue_Stress is meant to be run with the race detector: go test -race.
+func TestConcurrentLinkedQueue_Stress(t *testing.T) {
+	var producers = 8
+	var consumers = 8
+	var perProducer = 10_000
+	var queue = NewConcurrentLinkedQueue[int]()
+
+	var producersGroup sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		producersGroup.Go(func() {
+			for i := 0; i < perProducer; i++ {
+				queue.AddLast(p*perProducer + i)
+			}
+		})
+	}
+	var done = make(chan struct{})
+	var results = make([][]int, consumers)
+	var consumersGroup sync.WaitGroup
+	for c := 0; c < consumers; c++ {
+		consumersGroup.Go(func() {
+			for {
+				x, err := queue.TryRemoveFirst()
+				if err == nil {
+					results[c] = append(results[c], x)
+					continue
+				}
+				select {
+				case <-done:
+					if queue.Size() == 0 {
+						return
+					}
+				default:
+				}
+			}
+		})
+	}
+	producersGroup.Wait()
+	close(done)
+	consumersGroup.Wait()
+
+	var removed = make([]bool, producers*perProducer)
+	for _, result := range results {
+		var last = make([]int, producers)
+		for p := range last {
+			last[p] = -1
+		}
+		for _, x := range result {
+			if removed[x] {
+				t.Fatalf("%d has been removed twice", x)
+			}
+			removed[x] = true
+			p, i := x/perProducer, x%perProducer
+			if i <= last[p] {
+				t.Fatalf("elements of producer %d removed out of order: %d after %d", p, i, last[p])
+			}
+			last[p] = i
+		}
+	}
+	if notRemoved := not(removed); len(notRemoved) != 0 {
+		t.Fatalf("elements that should have been removed but have not: %d", len(notRemoved))
+	}
+}
@@ -0,0 +1,122 @@
+package collections
+
+import (
+	"cmp"
+	"context"
+	"sync"
+	"time"
+)
+
+type delayed[T any] struct {
+	value T
+	at    time.Time
+	seq   uint64
+}
+
+// DelayQueue an unbounded threadsafe queue whose elements can be removed only once their time has come. Elements
+// are kept in a Heap ordered by that time, elements due at the same time are removed in the order they have been
+// added.
+type DelayQueue[T any] struct {
+	clock   Clock
+	lock    sync.Mutex
+	heap    *Heap[delayed[T]]
+	nextSeq uint64
+	// added is notified whenever an element is added, as it may be due earlier than the one consumers wait for
+	added *signal
+}
+
+func NewDelayQueue[T any]() *DelayQueue[T] {
+	return NewDelayQueueWithClock[T](SystemClock{})
+}
+
+func NewDelayQueueWithClock[T any](clock Clock) *DelayQueue[T] {
+	return &DelayQueue[T]{
+		clock: clock,
+		heap: NewHeapWithCompare(0, func(d1, d2 delayed[T]) int {
+			if c := d1.at.Compare(d2.at); c != 0 {
+				return c
+			}
+			return cmp.Compare(d1.seq, d2.seq)
+		}),
+		added: newSignal(),
+	}
+}
+
+// Add adds an element which can be removed once the delay has passed.
+func (q *DelayQueue[T]) Add(t T, delay time.Duration) {
+	q.AddAt(t, q.clock.Now().Add(delay))
+}
+
+// AddAt adds an element which can be removed at the given time or later.
+func (q *DelayQueue[T]) AddAt(t T, at time.Time) {
+	q.lock.Lock()
+	q.heap.Add(delayed[T]{value: t, at: at, seq: q.nextSeq})
+	q.nextSeq++
+	q.lock.Unlock()
+	q.added.notify()
+}
+
+// RemoveFirst removes the element which is due first. It blocks until the element is due, waking up earlier if
+// an element due sooner is added, or until ctx is done.
+func (q *DelayQueue[T]) RemoveFirst(ctx context.Context) (t T, err error) {
+	for {
+		added := q.added.wait()
+		t, delay, ok := q.tryRemoveFirst()
+		if ok {
+			q.added.done()
+			return t, nil
+		}
+		var timer Timer
+		var fired <-chan time.Time
+		if delay >= 0 {
+			timer = q.clock.NewTimer(delay)
+			fired = timer.C()
+		}
+		select {
+		case <-added:
+		case <-fired:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		q.added.done()
+		if err != nil {
+			return t, err
+		}
+	}
+}
+
+// TryRemoveFirst removes the element which is due first if its time has come, otherwise it returns an error
+// immediately.
+func (q *DelayQueue[T]) TryRemoveFirst() (T, error) {
+	t, _, ok := q.tryRemoveFirst()
+	if !ok {
+		return t, &QueueError{Op: "TryRemoveFirst", Err: ErrQueueEmpty}
+	}
+	return t, nil
+}
+
+// Size returns the number of elements including those which are not due yet.
+func (q *DelayQueue[T]) Size() uint {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return uint(q.heap.Size())
+}
+
+// tryRemoveFirst removes the first element if it is due. Otherwise it returns how long until it is due, or a negative
+// delay if the queue is empty.
+func (q *DelayQueue[T]) tryRemoveFirst() (t T, delay time.Duration, ok bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	first, err := q.heap.GetFirst()
+	if err != nil {
+		return t, -1, false
+	}
+	if delay = first.at.Sub(q.clock.Now()); delay > 0 {
+		return t, delay, false
+	}
+	_, _ = q.heap.Remove()
+	return first.value, 0, true
+}
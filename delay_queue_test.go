@@ -0,0 +1,222 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock a Clock whose time moves only when Advance is called.
+type fakeClock struct {
+	lock   sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock  *fakeClock
+	at     time.Time
+	c      chan time.Time
+	active bool
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	t := &fakeTimer{clock: c, at: c.now.Add(d), c: make(chan time.Time, 1), active: true}
+	c.timers = append(c.timers, t)
+	c.fire()
+	return t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+	c.fire()
+}
+
+func (c *fakeClock) fire() {
+	active := c.timers[:0]
+	for _, t := range c.timers {
+		if t.active && !t.at.After(c.now) {
+			t.active = false
+			t.c <- c.now
+		}
+		if t.active {
+			active = append(active, t)
+		}
+	}
+	c.timers = active
+}
+
+// waitForTimer waits until there is an active timer firing at the given time.
+func (c *fakeClock) waitForTimer(t *testing.T, at time.Time) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		c.lock.Lock()
+		for _, timer := range c.timers {
+			if timer.at.Equal(at) {
+				c.lock.Unlock()
+				return
+			}
+		}
+		c.lock.Unlock()
+	}
+	t.Fatalf("expected a timer at %v", at)
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+	active := t.active
+	t.active = false
+	return active
+}
+
+func TestDelayQueue_TryRemoveFirst(t *testing.T) {
+	clock := newFakeClock()
+	q := NewDelayQueueWithClock[string](clock)
+	q.Add("a", 30*time.Second)
+	q.Add("b", 10*time.Second)
+	q.Add("c", 10*time.Second)
+	q.Add("d", 20*time.Second)
+	if _, err := q.TryRemoveFirst(); !errors.Is(err, ErrQueueEmpty) {
+		t.Fatalf("expected queue is empty error, got %v", err)
+	}
+	clock.Advance(20 * time.Second)
+	for _, expected := range []string{"b", "c", "d"} {
+		x, err := q.TryRemoveFirst()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != expected {
+			t.Fatalf("expected %s got %s", expected, x)
+		}
+	}
+	if _, err := q.TryRemoveFirst(); !errors.Is(err, ErrQueueEmpty) {
+		t.Fatalf("expected queue is empty error, got %v", err)
+	}
+	if q.Size() != 1 {
+		t.Fatalf("expected %d got %d", 1, q.Size())
+	}
+}
+
+func TestDelayQueue_RemoveFirstWaits(t *testing.T) {
+	clock := newFakeClock()
+	q := NewDelayQueueWithClock[string](clock)
+	q.Add("a", time.Minute)
+	removed := make(chan string)
+	go func() {
+		x, err := q.RemoveFirst(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		removed <- x
+	}()
+	clock.waitForTimer(t, clock.Now().Add(time.Minute))
+	clock.Advance(30 * time.Second)
+	select {
+	case x := <-removed:
+		t.Fatalf("expected no element before it is due, got %s", x)
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Advance(30 * time.Second)
+	if x := <-removed; x != "a" {
+		t.Fatalf("expected %s got %s", "a", x)
+	}
+}
+
+func TestDelayQueue_EarlierElementRearms(t *testing.T) {
+	clock := newFakeClock()
+	q := NewDelayQueueWithClock[string](clock)
+	q.Add("late", time.Hour)
+	removed := make(chan string)
+	go func() {
+		x, err := q.RemoveFirst(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		removed <- x
+	}()
+	clock.waitForTimer(t, clock.Now().Add(time.Hour))
+	q.Add("early", time.Second)
+	clock.waitForTimer(t, clock.Now().Add(time.Second))
+	clock.Advance(time.Second)
+	if x := <-removed; x != "early" {
+		t.Fatalf("expected %s got %s", "early", x)
+	}
+}
+
+func TestDelayQueue_RemoveFirstEmpty(t *testing.T) {
+	clock := newFakeClock()
+	q := NewDelayQueueWithClock[string](clock)
+	removed := make(chan string)
+	go func() {
+		x, err := q.RemoveFirst(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		removed <- x
+	}()
+	time.Sleep(10 * time.Millisecond)
+	q.Add("now", 0)
+	if x := <-removed; x != "now" {
+		t.Fatalf("expected %s got %s", "now", x)
+	}
+}
+
+func TestDelayQueue_Cancel(t *testing.T) {
+	clock := newFakeClock()
+	q := NewDelayQueueWithClock[string](clock)
+	q.Add("a", time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		_, err := q.RemoveFirst(ctx)
+		errs <- err
+	}()
+	clock.waitForTimer(t, clock.Now().Add(time.Minute))
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled error, got %v", err)
+	}
+	// the timer has been stopped
+	clock.lock.Lock()
+	defer clock.lock.Unlock()
+	if len(clock.timers) != 1 || clock.timers[0].active {
+		t.Fatalf("expected the timer to be stopped")
+	}
+}
+
+func TestDelayQueue_SystemClock(t *testing.T) {
+	q := NewDelayQueue[int]()
+	start := time.Now()
+	q.Add(1, 10*time.Millisecond)
+	x, err := q.RemoveFirst(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x != 1 {
+		t.Fatalf("expected %d got %d", 1, x)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Fatalf("expected to wait at least %v, waited %v", 10*time.Millisecond, elapsed)
+	}
+}
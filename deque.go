@@ -0,0 +1,8 @@
+package collections
+
+// Deque a queue that supports adding and removing elements at both ends.
+type Deque[T any] interface {
+	Queue[T]
+	AddFirst(T)
+	RemoveLast() (T, error)
+}
@@ -0,0 +1,154 @@
+package collections
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+type dequeTestCase struct {
+	name  string
+	deque Deque[int]
+}
+
+func createDequeTests() []dequeTestCase {
+	return []dequeTestCase{
+		{
+			name:  "array deque",
+			deque: NewArrayDeque[int](),
+		},
+		{
+			name:  "array deque with initial capacity",
+			deque: NewArrayDequeWithInitialCapacity[int](4),
+		},
+		{
+			name:  "linked deque",
+			deque: NewLinkedDeque[int](),
+		},
+		{
+			name:  "segmented queue",
+			deque: NewSegmentedQueueWithSegmentSize[int](4),
+		},
+	}
+}
+
+func TestDeque_HappyPath(t *testing.T) {
+	for _, test := range createDequeTests() {
+		t.Run(test.name, func(t *testing.T) {
+			var n = 10
+			for i := 0; i < n; i++ {
+				test.deque.AddFirst(-i - 1)
+				test.deque.AddLast(i)
+			}
+			if test.deque.Size() != uint(2*n) {
+				t.Fatalf("expected %d got %d", 2*n, test.deque.Size())
+			}
+			first, err := test.deque.PeekFirst()
+			if err != nil || first != -n {
+				t.Fatalf("expected %d got %d, %v", -n, first, err)
+			}
+			last, err := test.deque.PeekLast()
+			if err != nil || last != n-1 {
+				t.Fatalf("expected %d got %d, %v", n-1, last, err)
+			}
+			for i := -n; i < n; i++ {
+				x, err := test.deque.RemoveFirst()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if x != i {
+					t.Fatalf("expected %d got %d", i, x)
+				}
+			}
+			if _, err := test.deque.RemoveFirst(); err == nil {
+				t.Fatalf("expected error when removing from an empty deque")
+			}
+			if _, err := test.deque.RemoveLast(); err == nil {
+				t.Fatalf("expected error when removing from an empty deque")
+			}
+			if _, err := test.deque.PeekFirst(); err == nil {
+				t.Fatalf("expected error when peeking into an empty deque")
+			}
+			if _, err := test.deque.PeekLast(); err == nil {
+				t.Fatalf("expected error when peeking into an empty deque")
+			}
+		})
+	}
+}
+
+func TestDeque_Randomized(t *testing.T) {
+	for _, test := range createDequeTests() {
+		t.Run(test.name, func(t *testing.T) {
+			var expected []int
+			for i := 0; i < 10_000; i++ {
+				switch rand.Intn(4) {
+				case 0:
+					test.deque.AddFirst(i)
+					expected = append([]int{i}, expected...)
+				case 1:
+					test.deque.AddLast(i)
+					expected = append(expected, i)
+				case 2:
+					x, err := test.deque.RemoveFirst()
+					if len(expected) == 0 {
+						if err == nil {
+							t.Fatalf("expected error, got %d", x)
+						}
+						continue
+					}
+					if err != nil || x != expected[0] {
+						t.Fatalf("expected %d got %d, %v", expected[0], x, err)
+					}
+					expected = expected[1:]
+				case 3:
+					x, err := test.deque.RemoveLast()
+					if len(expected) == 0 {
+						if err == nil {
+							t.Fatalf("expected error, got %d", x)
+						}
+						continue
+					}
+					if err != nil || x != expected[len(expected)-1] {
+						t.Fatalf("expected %d got %d, %v", expected[len(expected)-1], x, err)
+					}
+					expected = expected[:len(expected)-1]
+				}
+				if test.deque.Size() != uint(len(expected)) {
+					t.Fatalf("expected size %d got %d", len(expected), test.deque.Size())
+				}
+			}
+		})
+	}
+}
+
+func TestDeque_Iterators(t *testing.T) {
+	arrayDeque := NewArrayDeque[int]()
+	linkedDeque := NewLinkedDeque[int]()
+	for i := 0; i < 5; i++ {
+		arrayDeque.AddLast(i)
+		arrayDeque.AddFirst(-i - 1)
+		linkedDeque.AddLast(i)
+		linkedDeque.AddFirst(-i - 1)
+	}
+	expected := []int{-5, -4, -3, -2, -1, 0, 1, 2, 3, 4}
+	if all := slices.Collect(arrayDeque.All()); !slices.Equal(all, expected) {
+		t.Fatalf("expected %v got %v", expected, all)
+	}
+	if all := slices.Collect(linkedDeque.All()); !slices.Equal(all, expected) {
+		t.Fatalf("expected %v got %v", expected, all)
+	}
+	backward := slices.Clone(expected)
+	slices.Reverse(backward)
+	if all := slices.Collect(arrayDeque.Backward()); !slices.Equal(all, backward) {
+		t.Fatalf("expected %v got %v", backward, all)
+	}
+	if all := slices.Collect(linkedDeque.Backward()); !slices.Equal(all, backward) {
+		t.Fatalf("expected %v got %v", backward, all)
+	}
+	if drained := slices.Collect(arrayDeque.Drain()); !slices.Equal(drained, expected) || arrayDeque.Size() != 0 {
+		t.Fatalf("expected %v got %v", expected, drained)
+	}
+	if drained := slices.Collect(linkedDeque.Drain()); !slices.Equal(drained, expected) || linkedDeque.Size() != 0 {
+		t.Fatalf("expected %v got %v", expected, drained)
+	}
+}
@@ -0,0 +1,530 @@
+package collections
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	recordEnqueue byte = 1
+	recordDequeue byte = 2
+	// recordHeaderSize kind (1 byte), sequence number (8), payload length (4) and CRC-32 of all of them and
+	// the payload (4).
+	recordHeaderSize          = 17
+	defaultDurableSegmentSize = 64 << 20
+	segmentSuffix             = ".log"
+)
+
+var errCorruptRecord = errors.New("corrupt record")
+
+// SyncPolicy decides when a DurableQueue flushes its log to stable storage. The zero value syncs after every
+// operation.
+type SyncPolicy struct {
+	everyOps int
+	interval time.Duration
+}
+
+// SyncEveryOp syncs the log after every operation, so no operation that has returned is lost on a crash.
+func SyncEveryOp() SyncPolicy {
+	return SyncPolicy{everyOps: 1}
+}
+
+// SyncBatched syncs the log after every n operations, so up to n-1 operations may be lost on a crash.
+func SyncBatched(n int) SyncPolicy {
+	return SyncPolicy{everyOps: max(n, 1)}
+}
+
+// SyncInterval syncs the log in the background once per interval if it has changed, so operations of up to one
+// interval may be lost on a crash. A non-positive interval syncs after every operation.
+func SyncInterval(interval time.Duration) SyncPolicy {
+	if interval <= 0 {
+		return SyncEveryOp()
+	}
+	return SyncPolicy{interval: interval}
+}
+
+// DurableQueueOptions optional settings of a DurableQueue, the zero value uses the defaults.
+type DurableQueueOptions struct {
+	// SyncPolicy when the log is synced, SyncEveryOp by default.
+	SyncPolicy SyncPolicy
+	// SegmentSize size in bytes after which a new segment file is started, 64 MiB by default.
+	SegmentSize int64
+}
+
+type durableEntry[T any] struct {
+	seq   uint64
+	value T
+}
+
+type durableSegment struct {
+	id uint64
+	// lastEnqueue sequence number of the last element added in the segment, valid only if hasEnqueue is set
+	lastEnqueue uint64
+	hasEnqueue  bool
+}
+
+// DurableQueue an implementation of QueueWithLimit which survives restarts. Every added and removed element is
+// appended to a write-ahead log of segment files in a directory, and the queue is rebuilt from the log when it is
+// opened again. A torn record at the end of the log, left by a crash in the middle of a write, is truncated.
+// Segments whose elements have all been removed are deleted.
+//
+// Elements are also kept in memory, so the queue is as fast as a StandardQueueWithLimit for reads. An element removed
+// shortly before a crash may be returned again after a restart if its removal has not been synced yet. A directory
+// must not be used by more than one queue at a time.
+type DurableQueue[T any] struct {
+	dir      string
+	maxSize  uint
+	codec    Codec[T]
+	options  DurableQueueOptions
+	lock     sync.Mutex
+	queue    *ArrayQueue[durableEntry[T]]
+	nextSeq  uint64
+	segments []durableSegment
+	// file the last segment, the only one being written to
+	file     *os.File
+	fileSize int64
+	unsynced int
+	// err a failed write or sync after which the state of the log is unknown, all later operations return it
+	err      error
+	closed   bool
+	notEmpty *signal
+	notFull  *signal
+	stopSync chan struct{}
+	syncDone chan struct{}
+}
+
+// NewDurableQueue opens the queue stored in dir, creating the directory if it does not exist yet.
+func NewDurableQueue[T any](dir string, maxSize uint, codec Codec[T], options DurableQueueOptions) (*DurableQueue[T], error) {
+	if maxSize == 0 {
+		return nil, errors.New("maxSize must be positive")
+	}
+	if options.SyncPolicy == (SyncPolicy{}) {
+		options.SyncPolicy = SyncEveryOp()
+	}
+	if options.SegmentSize <= 0 {
+		options.SegmentSize = defaultDurableSegmentSize
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	q := &DurableQueue[T]{
+		dir:      dir,
+		maxSize:  maxSize,
+		codec:    codec,
+		options:  options,
+		queue:    NewArrayQueueWithInitialCapacity[durableEntry[T]](maxSize),
+		notEmpty: newSignal(),
+		notFull:  newSignal(),
+	}
+	if err := q.recover(); err != nil {
+		return nil, err
+	}
+	if interval := options.SyncPolicy.interval; interval > 0 {
+		q.stopSync = make(chan struct{})
+		q.syncDone = make(chan struct{})
+		go q.syncPeriodically(interval)
+	}
+	return q, nil
+}
+
+func (q *DurableQueue[T]) AddLast(ctx context.Context, t T) error {
+	payload, err := q.codec.Encode(t)
+	if err != nil {
+		return err
+	}
+	var addErr error
+	err = await(ctx, q.notFull, 0, func() (ok bool) {
+		ok, addErr = q.tryAddLast("AddLast", t, payload)
+		return ok
+	})
+	if err != nil {
+		return err
+	}
+	return addErr
+}
+
+func (q *DurableQueue[T]) TryAddLast(t T) error {
+	payload, err := q.codec.Encode(t)
+	if err != nil {
+		return err
+	}
+	ok, err := q.tryAddLast("TryAddLast", t, payload)
+	if !ok {
+		return &QueueError{Op: "TryAddLast", Capacity: q.maxSize, Err: ErrQueueFull}
+	}
+	return err
+}
+
+func (q *DurableQueue[T]) RemoveFirst(ctx context.Context) (t T, err error) {
+	var removeErr error
+	err = await(ctx, q.notEmpty, 0, func() (ok bool) {
+		t, ok, removeErr = q.tryRemoveFirst("RemoveFirst")
+		return ok
+	})
+	if err != nil {
+		return t, err
+	}
+	return t, removeErr
+}
+
+func (q *DurableQueue[T]) TryRemoveFirst() (t T, err error) {
+	t, ok, err := q.tryRemoveFirst("TryRemoveFirst")
+	if !ok {
+		return t, &QueueError{Op: "TryRemoveFirst", Capacity: q.maxSize, Err: ErrQueueEmpty}
+	}
+	return t, err
+}
+
+func (q *DurableQueue[T]) MaxSize() uint {
+	return q.maxSize
+}
+
+func (q *DurableQueue[T]) Size() uint {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.queue.Size()
+}
+
+// Close syncs and closes the log. Blocked and later operations return ErrClosed.
+func (q *DurableQueue[T]) Close() error {
+	q.lock.Lock()
+	if q.closed {
+		q.lock.Unlock()
+		return nil
+	}
+	q.closed = true
+	q.lock.Unlock()
+	if q.stopSync != nil {
+		close(q.stopSync)
+		<-q.syncDone
+	}
+	q.notEmpty.notify()
+	q.notFull.notify()
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	var err error
+	if q.err == nil {
+		err = q.file.Sync()
+	}
+	return errors.Join(err, q.file.Close())
+}
+
+// tryAddLast returns false if the queue is full, otherwise it has either added the element or failed with an error.
+func (q *DurableQueue[T]) tryAddLast(op string, t T, payload []byte) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if err := q.check(op); err != nil {
+		return true, err
+	}
+	if q.queue.Size() >= q.maxSize {
+		return false, nil
+	}
+	seq := q.nextSeq
+	if err := q.append(recordEnqueue, seq, payload); err != nil {
+		return true, err
+	}
+	q.queue.AddLast(durableEntry[T]{seq: seq, value: t})
+	q.nextSeq++
+	q.notEmpty.notify()
+	q.rotateIfNeeded()
+	return true, nil
+}
+
+// tryRemoveFirst returns false if the queue is empty, otherwise it has either removed an element or failed with
+// an error.
+func (q *DurableQueue[T]) tryRemoveFirst(op string) (t T, ok bool, err error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if err := q.check(op); err != nil {
+		return t, true, err
+	}
+	first, err := q.queue.PeekFirst()
+	if err != nil {
+		return t, false, nil
+	}
+	if err := q.append(recordDequeue, first.seq, nil); err != nil {
+		return t, true, err
+	}
+	_, _ = q.queue.RemoveFirst()
+	q.notFull.notify()
+	q.rotateIfNeeded()
+	// a segment which could not be removed is retried after the next removal
+	_ = q.compact()
+	return first.value, true, nil
+}
+
+func (q *DurableQueue[T]) check(op string) error {
+	if q.closed {
+		return &QueueError{Op: op, Capacity: q.maxSize, Err: ErrClosed}
+	}
+	return q.err
+}
+
+// append writes a record to the last segment and syncs it if the sync policy says so. The caller applies
+// the operation to the in-memory state only if it succeeds.
+func (q *DurableQueue[T]) append(kind byte, seq uint64, payload []byte) error {
+	record := make([]byte, recordHeaderSize+len(payload))
+	record[0] = kind
+	binary.LittleEndian.PutUint64(record[1:9], seq)
+	binary.LittleEndian.PutUint32(record[9:13], uint32(len(payload)))
+	copy(record[recordHeaderSize:], payload)
+	crc := crc32.NewIEEE()
+	crc.Write(record[:13])
+	crc.Write(payload)
+	binary.LittleEndian.PutUint32(record[13:17], crc.Sum32())
+
+	if _, err := q.file.Write(record); err != nil {
+		// drop a partially written record, so that later records do not follow garbage
+		if truncateErr := q.file.Truncate(q.fileSize); truncateErr != nil {
+			q.err = err
+		}
+		return err
+	}
+	q.fileSize += int64(len(record))
+	if kind == recordEnqueue {
+		segment := &q.segments[len(q.segments)-1]
+		segment.lastEnqueue = seq
+		segment.hasEnqueue = true
+	}
+	q.unsynced++
+	if everyOps := q.options.SyncPolicy.everyOps; everyOps > 0 && q.unsynced >= everyOps {
+		return q.sync()
+	}
+	return nil
+}
+
+func (q *DurableQueue[T]) sync() error {
+	if err := q.file.Sync(); err != nil {
+		q.err = err
+		return err
+	}
+	q.unsynced = 0
+	return nil
+}
+
+func (q *DurableQueue[T]) syncPeriodically(interval time.Duration) {
+	defer close(q.syncDone)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-q.stopSync:
+			return
+		case <-ticker.C:
+			q.lock.Lock()
+			if q.unsynced > 0 && q.err == nil {
+				_ = q.sync()
+			}
+			q.lock.Unlock()
+		}
+	}
+}
+
+// rotateIfNeeded starts a new segment once the last one has grown over the segment size. The operation which
+// triggered it has already succeeded, so a failure is only reported by later operations.
+func (q *DurableQueue[T]) rotateIfNeeded() {
+	if q.fileSize < q.options.SegmentSize {
+		return
+	}
+	if err := q.sync(); err != nil {
+		return
+	}
+	if err := q.file.Close(); err != nil {
+		q.err = err
+		return
+	}
+	id := q.segments[len(q.segments)-1].id + 1
+	if err := q.createSegment(id); err != nil {
+		q.err = err
+		return
+	}
+	_ = q.compact()
+}
+
+// compact removes segments from the beginning of the log whose added elements have all been removed. Their dequeue
+// records are not needed either, as they only refer to elements of the same or earlier segments.
+func (q *DurableQueue[T]) compact() error {
+	head := q.nextSeq
+	if first, err := q.queue.PeekFirst(); err == nil {
+		head = first.seq
+	}
+	removed := false
+	defer func() {
+		if removed {
+			syncDir(q.dir)
+		}
+	}()
+	for len(q.segments) > 1 {
+		segment := q.segments[0]
+		if segment.hasEnqueue && segment.lastEnqueue >= head {
+			break
+		}
+		if err := os.Remove(q.segmentPath(segment.id)); err != nil {
+			return err
+		}
+		removed = true
+		q.segments = q.segments[1:]
+	}
+	return nil
+}
+
+func (q *DurableQueue[T]) createSegment(id uint64) error {
+	file, err := os.OpenFile(q.segmentPath(id), os.O_WRONLY|os.O_CREATE|os.O_EXCL|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	q.file = file
+	q.fileSize = 0
+	q.segments = append(q.segments, durableSegment{id: id})
+	syncDir(q.dir)
+	return nil
+}
+
+func (q *DurableQueue[T]) segmentPath(id uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", id, segmentSuffix))
+}
+
+// recover replays all segments in order and opens the last one for writing.
+func (q *DurableQueue[T]) recover() error {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return err
+	}
+	var ids []uint64
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), segmentSuffix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		id, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	if len(ids) == 0 {
+		return q.createSegment(0)
+	}
+	for i, id := range ids {
+		segment, err := q.replay(id, i == len(ids)-1)
+		if err != nil {
+			return err
+		}
+		q.segments = append(q.segments, segment)
+	}
+	path := q.segmentPath(ids[len(ids)-1])
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return errors.Join(err, file.Close())
+	}
+	q.file = file
+	q.fileSize = info.Size()
+	return q.compact()
+}
+
+// replay applies records of a segment to the in-memory state. A record cut short or corrupted at the end of the last
+// segment is the result of a crash during a write, so it is truncated. Anywhere else it is an error.
+func (q *DurableQueue[T]) replay(id uint64, last bool) (durableSegment, error) {
+	segment := durableSegment{id: id}
+	path := q.segmentPath(id)
+	file, err := os.Open(path)
+	if err != nil {
+		return segment, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return segment, err
+	}
+	reader := bufio.NewReader(file)
+	var offset int64
+	for offset < info.Size() {
+		kind, seq, payload, err := readRecord(reader, info.Size()-offset)
+		if err != nil {
+			if last && (errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, errCorruptRecord)) {
+				return segment, os.Truncate(path, offset)
+			}
+			return segment, fmt.Errorf("%s at offset %d: %w", path, offset, err)
+		}
+		offset += int64(recordHeaderSize + len(payload))
+		switch kind {
+		case recordEnqueue:
+			value, err := q.codec.Decode(payload)
+			if err != nil {
+				return segment, fmt.Errorf("%s at offset %d: %w", path, offset, err)
+			}
+			q.queue.AddLast(durableEntry[T]{seq: seq, value: value})
+			q.nextSeq = seq + 1
+			segment.lastEnqueue = seq
+			segment.hasEnqueue = true
+		case recordDequeue:
+			for {
+				first, err := q.queue.PeekFirst()
+				if err != nil || first.seq > seq {
+					break
+				}
+				_, _ = q.queue.RemoveFirst()
+			}
+			q.nextSeq = max(q.nextSeq, seq+1)
+		}
+	}
+	return segment, nil
+}
+
+// readRecord reads a record from r, which has the given number of bytes remaining.
+func readRecord(r io.Reader, remaining int64) (kind byte, seq uint64, payload []byte, err error) {
+	header := make([]byte, recordHeaderSize)
+	if _, err = io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return
+	}
+	kind = header[0]
+	seq = binary.LittleEndian.Uint64(header[1:9])
+	length := binary.LittleEndian.Uint32(header[9:13])
+	if kind != recordEnqueue && kind != recordDequeue {
+		return kind, seq, nil, errCorruptRecord
+	}
+	if int64(length) > remaining-recordHeaderSize {
+		return kind, seq, nil, io.ErrUnexpectedEOF
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return
+	}
+	crc := crc32.NewIEEE()
+	crc.Write(header[:13])
+	crc.Write(payload)
+	if crc.Sum32() != binary.LittleEndian.Uint32(header[13:17]) {
+		return kind, seq, nil, errCorruptRecord
+	}
+	return kind, seq, payload, nil
+}
+
+// syncDir makes a created or removed file in dir durable. Not all platforms support syncing a directory, so errors
+// are ignored.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+}
@@ -0,0 +1,332 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// crash abandons the queue without syncing or closing it properly, as if the process has been killed. Data already
+// written to the files survives, as it would in the page cache of the operating system.
+func crash[T any](q *DurableQueue[T]) {
+	if q.stopSync != nil {
+		close(q.stopSync)
+		<-q.syncDone
+	}
+	_ = q.file.Close()
+}
+
+func openDurableQueue(t *testing.T, dir string, maxSize uint, options DurableQueueOptions) *DurableQueue[int] {
+	q, err := NewDurableQueue[int](dir, maxSize, GobCodec[int]{}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func expectElements(t *testing.T, q *DurableQueue[int], expected ...int) {
+	if q.Size() != uint(len(expected)) {
+		t.Fatalf("expected size %d got %d", len(expected), q.Size())
+	}
+	for _, e := range expected {
+		x, err := q.TryRemoveFirst()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != e {
+			t.Fatalf("expected %d got %d", e, x)
+		}
+	}
+}
+
+func TestDurableQueue_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	q := openDurableQueue(t, dir, 100, DurableQueueOptions{})
+	for i := 0; i < 10; i++ {
+		if err := q.TryAddLast(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := q.TryRemoveFirst(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	q = openDurableQueue(t, dir, 100, DurableQueueOptions{})
+	defer q.Close()
+	expectElements(t, q, 3, 4, 5, 6, 7, 8, 9)
+	if err := q.TryAddLast(10); err != nil {
+		t.Fatal(err)
+	}
+	expectElements(t, q, 10)
+}
+
+func TestDurableQueue_Crash(t *testing.T) {
+	policies := []struct {
+		name   string
+		policy SyncPolicy
+	}{
+		{"every op", SyncEveryOp()},
+		{"batched", SyncBatched(4)},
+		{"interval", SyncInterval(time.Millisecond)},
+	}
+	for _, test := range policies {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			q := openDurableQueue(t, dir, 100, DurableQueueOptions{SyncPolicy: test.policy})
+			for i := 0; i < 10; i++ {
+				if err := q.TryAddLast(i); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := q.TryRemoveFirst(); err != nil {
+				t.Fatal(err)
+			}
+			crash(q)
+
+			q = openDurableQueue(t, dir, 100, DurableQueueOptions{SyncPolicy: test.policy})
+			defer q.Close()
+			expectElements(t, q, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+		})
+	}
+}
+
+func TestDurableQueue_TornWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(t *testing.T, path string)
+		// expected elements after recovery, the last one is lost with its record if the record itself is damaged
+		expected []int
+	}{
+		{"cut record", func(t *testing.T, path string) {
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Truncate(path, info.Size()-3); err != nil {
+				t.Fatal(err)
+			}
+		}, []int{0, 1}},
+		{"cut header", func(t *testing.T, path string) {
+			appendBytes(t, path, []byte{recordEnqueue, 1, 2})
+		}, []int{0, 1, 2}},
+		{"garbage", func(t *testing.T, path string) {
+			appendBytes(t, path, []byte("not a record at all, just garbage"))
+		}, []int{0, 1, 2}},
+		{"bad checksum", func(t *testing.T, path string) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data[len(data)-1] ^= 0xff
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}, []int{0, 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			q := openDurableQueue(t, dir, 100, DurableQueueOptions{})
+			for i := 0; i < 3; i++ {
+				if err := q.TryAddLast(i); err != nil {
+					t.Fatal(err)
+				}
+			}
+			crash(q)
+			files := segmentFiles(t, dir)
+			test.corrupt(t, files[len(files)-1])
+
+			q = openDurableQueue(t, dir, 100, DurableQueueOptions{})
+			expectElements(t, q, test.expected...)
+			if err := q.TryAddLast(3); err != nil {
+				t.Fatal(err)
+			}
+			crash(q)
+
+			// the torn record has been truncated, so the new one can be read back
+			q = openDurableQueue(t, dir, 100, DurableQueueOptions{})
+			defer q.Close()
+			expectElements(t, q, 3)
+		})
+	}
+}
+
+func appendBytes(t *testing.T, path string, data []byte) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDurableQueue_CorruptedMiddleSegment(t *testing.T) {
+	dir := t.TempDir()
+	q := openDurableQueue(t, dir, 100, DurableQueueOptions{SegmentSize: 64})
+	for i := 0; i < 20; i++ {
+		if err := q.TryAddLast(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+	files := segmentFiles(t, dir)
+	if len(files) < 3 {
+		t.Fatalf("expected at least %d segments got %d", 3, len(files))
+	}
+	appendBytes(t, files[0], []byte{recordEnqueue})
+	if _, err := NewDurableQueue[int](dir, 100, GobCodec[int]{}, DurableQueueOptions{}); err == nil {
+		t.Fatalf("expected error opening a queue with a corrupted segment")
+	}
+}
+
+func TestDurableQueue_Compaction(t *testing.T) {
+	dir := t.TempDir()
+	options := DurableQueueOptions{SyncPolicy: SyncBatched(100), SegmentSize: 256}
+	q := openDurableQueue(t, dir, 10, options)
+	next := 0
+	for i := 0; i < 1000; i++ {
+		if err := q.TryAddLast(i); err != nil {
+			t.Fatal(err)
+		}
+		if i%10 == 9 {
+			for j := 0; j < 5; j++ {
+				x, err := q.TryRemoveFirst()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if x != next {
+					t.Fatalf("expected %d got %d", next, x)
+				}
+				next++
+			}
+		}
+		if q.Size() == q.MaxSize() {
+			for q.Size() > 0 {
+				if _, err := q.TryRemoveFirst(); err != nil {
+					t.Fatal(err)
+				}
+				next++
+			}
+		}
+	}
+	if files := segmentFiles(t, dir); len(files) > 4 {
+		t.Fatalf("expected at most %d segments got %d", 4, len(files))
+	}
+	expected := make([]int, 0, q.Size())
+	for x := range q.queue.All() {
+		expected = append(expected, x.value)
+	}
+	crash(q)
+
+	q = openDurableQueue(t, dir, 10, options)
+	defer q.Close()
+	expectElements(t, q, expected...)
+}
+
+func TestDurableQueue_Blocking(t *testing.T) {
+	q := openDurableQueue(t, t.TempDir(), 2, DurableQueueOptions{})
+	defer q.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		if err := q.AddLast(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.AddLast(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded error, got %v", err)
+	}
+	if err := q.TryAddLast(2); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected queue is full error, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_, _ = q.TryRemoveFirst()
+	}()
+	if err := q.AddLast(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+	expectElements(t, q, 1, 2)
+}
+
+func TestDurableQueue_Close(t *testing.T) {
+	q := openDurableQueue(t, t.TempDir(), 2, DurableQueueOptions{SyncPolicy: SyncInterval(time.Millisecond)})
+	errs := make(chan error)
+	go func() {
+		_, err := q.RemoveFirst(context.Background())
+		errs <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected queue is closed error, got %v", err)
+	}
+	if err := q.TryAddLast(1); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected queue is closed error, got %v", err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDurableQueue_JSONCodec(t *testing.T) {
+	type task struct {
+		Name     string
+		Priority int
+	}
+	dir := t.TempDir()
+	q, err := NewDurableQueue[task](dir, 10, JSONCodec[task]{}, DurableQueueOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.TryAddLast(task{Name: "build", Priority: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err = NewDurableQueue[task](dir, 10, JSONCodec[task]{}, DurableQueueOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	x, err := q.TryRemoveFirst()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Name != "build" || x.Priority != 2 {
+		t.Fatalf("expected %v got %v", task{Name: "build", Priority: 2}, x)
+	}
+}
+
+func TestSyncInterval_NonPositive(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if policy := SyncInterval(interval); policy != SyncEveryOp() {
+			t.Fatalf("expected %v got %v for interval %v", SyncEveryOp(), policy, interval)
+		}
+	}
+}
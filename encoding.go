@@ -0,0 +1,223 @@
+package collections
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"iter"
+	"slices"
+)
+
+// Queues and heaps are encoded as a plain list of their elements: a JSON array for the JSON encoding and a gob
+// encoded slice for the binary and gob encodings. Queues are encoded in FIFO order, heaps in the order of their
+// underlying array. Decoding replaces the current contents but keeps settings such as the shrink policy.
+
+func marshalJSON[T any](seq iter.Seq[T]) ([]byte, error) {
+	values := slices.Collect(seq)
+	if values == nil {
+		values = []T{}
+	}
+	return json.Marshal(values)
+}
+
+func unmarshalJSON[T any](data []byte) ([]T, error) {
+	var values []T
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func marshalBinary[T any](seq iter.Seq[T]) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(slices.Collect(seq)); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func unmarshalBinary[T any](data []byte) ([]T, error) {
+	var values []T
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (q *LinkedQueue[T]) MarshalJSON() ([]byte, error) {
+	return marshalJSON(q.All())
+}
+
+func (q *LinkedQueue[T]) UnmarshalJSON(data []byte) error {
+	values, err := unmarshalJSON[T](data)
+	if err != nil {
+		return err
+	}
+	q.reset(values)
+	return nil
+}
+
+func (q *LinkedQueue[T]) MarshalBinary() ([]byte, error) {
+	return marshalBinary(q.All())
+}
+
+func (q *LinkedQueue[T]) UnmarshalBinary(data []byte) error {
+	values, err := unmarshalBinary[T](data)
+	if err != nil {
+		return err
+	}
+	q.reset(values)
+	return nil
+}
+
+func (q *LinkedQueue[T]) GobEncode() ([]byte, error) {
+	return q.MarshalBinary()
+}
+
+func (q *LinkedQueue[T]) GobDecode(data []byte) error {
+	return q.UnmarshalBinary(data)
+}
+
+func (q *LinkedQueue[T]) reset(values []T) {
+	*q = LinkedQueue[T]{}
+	q.AddAll(values...)
+}
+
+func (q *ArrayQueue[T]) MarshalJSON() ([]byte, error) {
+	return marshalJSON(q.All())
+}
+
+func (q *ArrayQueue[T]) UnmarshalJSON(data []byte) error {
+	values, err := unmarshalJSON[T](data)
+	if err != nil {
+		return err
+	}
+	q.reset(values)
+	return nil
+}
+
+func (q *ArrayQueue[T]) MarshalBinary() ([]byte, error) {
+	return marshalBinary(q.All())
+}
+
+func (q *ArrayQueue[T]) UnmarshalBinary(data []byte) error {
+	values, err := unmarshalBinary[T](data)
+	if err != nil {
+		return err
+	}
+	q.reset(values)
+	return nil
+}
+
+func (q *ArrayQueue[T]) GobEncode() ([]byte, error) {
+	return q.MarshalBinary()
+}
+
+func (q *ArrayQueue[T]) GobDecode(data []byte) error {
+	return q.UnmarshalBinary(data)
+}
+
+func (q *ArrayQueue[T]) reset(values []T) {
+	*q = ArrayQueue[T]{
+		array:        values,
+		size:         uint(len(values)),
+		shrinkPolicy: q.shrinkPolicy,
+	}
+}
+
+func (q *SimpleArrayQueue[T]) MarshalJSON() ([]byte, error) {
+	return marshalJSON(q.All())
+}
+
+func (q *SimpleArrayQueue[T]) UnmarshalJSON(data []byte) error {
+	values, err := unmarshalJSON[T](data)
+	if err != nil {
+		return err
+	}
+	q.reset(values)
+	return nil
+}
+
+func (q *SimpleArrayQueue[T]) MarshalBinary() ([]byte, error) {
+	return marshalBinary(q.All())
+}
+
+func (q *SimpleArrayQueue[T]) UnmarshalBinary(data []byte) error {
+	values, err := unmarshalBinary[T](data)
+	if err != nil {
+		return err
+	}
+	q.reset(values)
+	return nil
+}
+
+func (q *SimpleArrayQueue[T]) GobEncode() ([]byte, error) {
+	return q.MarshalBinary()
+}
+
+func (q *SimpleArrayQueue[T]) GobDecode(data []byte) error {
+	return q.UnmarshalBinary(data)
+}
+
+func (q *SimpleArrayQueue[T]) reset(values []T) {
+	*q = SimpleArrayQueue[T]{
+		queue:        values,
+		capacity:     uint(cap(values)),
+		shrinkPolicy: q.shrinkPolicy,
+	}
+}
+
+var errHeapWithoutCompare = errors.New("heap has no compare function, create it with NewHeap or NewHeapWithCompare before decoding")
+
+func (heap *Heap[T]) MarshalJSON() ([]byte, error) {
+	return marshalJSON(heap.All())
+}
+
+// UnmarshalJSON replaces elements of the heap with the decoded ones. The heap must have been created with NewHeap
+// or NewHeapWithCompare, as the compare function cannot be encoded.
+func (heap *Heap[T]) UnmarshalJSON(data []byte) error {
+	if heap.compare == nil {
+		return errHeapWithoutCompare
+	}
+	values, err := unmarshalJSON[T](data)
+	if err != nil {
+		return err
+	}
+	heap.reset(values)
+	return nil
+}
+
+func (heap *Heap[T]) MarshalBinary() ([]byte, error) {
+	return marshalBinary(heap.All())
+}
+
+// UnmarshalBinary replaces elements of the heap with the decoded ones. The heap must have been created with
+// NewHeap or NewHeapWithCompare, as the compare function cannot be encoded.
+func (heap *Heap[T]) UnmarshalBinary(data []byte) error {
+	if heap.compare == nil {
+		return errHeapWithoutCompare
+	}
+	values, err := unmarshalBinary[T](data)
+	if err != nil {
+		return err
+	}
+	heap.reset(values)
+	return nil
+}
+
+func (heap *Heap[T]) GobEncode() ([]byte, error) {
+	return heap.MarshalBinary()
+}
+
+func (heap *Heap[T]) GobDecode(data []byte) error {
+	return heap.UnmarshalBinary(data)
+}
+
+// reset replaces elements of the heap and restores the heap order, the decoded data may come from a heap
+// with a different compare function.
+func (heap *Heap[T]) reset(values []T) {
+	heap.array = values
+	heap.size = len(values)
+	heap.heapify()
+}
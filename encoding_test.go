@@ -0,0 +1,173 @@
+package collections
+
+import (
+	"bytes"
+	"cmp"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+type encodableQueue interface {
+	Queue[int]
+	json.Marshaler
+	json.Unmarshaler
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+	gob.GobEncoder
+	gob.GobDecoder
+}
+
+type codec struct {
+	name   string
+	encode func(v any) ([]byte, error)
+	decode func(data []byte, v any) error
+}
+
+func createCodecs() []codec {
+	return []codec{
+		{
+			name:   "json",
+			encode: json.Marshal,
+			decode: json.Unmarshal,
+		},
+		{
+			name: "binary",
+			encode: func(v any) ([]byte, error) {
+				return v.(encoding.BinaryMarshaler).MarshalBinary()
+			},
+			decode: func(data []byte, v any) error {
+				return v.(encoding.BinaryUnmarshaler).UnmarshalBinary(data)
+			},
+		},
+		{
+			name: "gob",
+			encode: func(v any) ([]byte, error) {
+				var buffer bytes.Buffer
+				err := gob.NewEncoder(&buffer).Encode(v)
+				return buffer.Bytes(), err
+			},
+			decode: func(data []byte, v any) error {
+				return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+			},
+		},
+	}
+}
+
+func TestQueue_Encoding(t *testing.T) {
+	queues := []struct {
+		name     string
+		newQueue func() encodableQueue
+	}{
+		{name: "linked queue", newQueue: func() encodableQueue { return NewLinkedQueue[int]() }},
+		{name: "array queue", newQueue: func() encodableQueue { return NewArrayQueueWithInitialCapacity[int](8) }},
+		{name: "simple array queue", newQueue: func() encodableQueue { return NewSimpleArrayQueue[int]() }},
+	}
+	for _, queue := range queues {
+		for _, codec := range createCodecs() {
+			for _, n := range []int{0, 1, 10} {
+				t.Run(fmt.Sprintf("%s %s %d elements", queue.name, codec.name, n), func(t *testing.T) {
+					q := queue.newQueue()
+					// remove a few elements first, so that the array queue wraps around
+					for i := 0; i < 5; i++ {
+						q.AddLast(-1)
+					}
+					for i := 0; i < 5; i++ {
+						_, _ = q.RemoveFirst()
+					}
+					for i := 0; i < n; i++ {
+						q.AddLast(i)
+					}
+					data, err := codec.encode(q)
+					if err != nil {
+						t.Fatal(err)
+					}
+					decoded := queue.newQueue()
+					decoded.AddLast(-1)
+					if err := codec.decode(data, decoded); err != nil {
+						t.Fatal(err)
+					}
+					if decoded.Size() != uint(n) {
+						t.Fatalf("expected %d got %d", n, decoded.Size())
+					}
+					for i := 0; i < n; i++ {
+						x, err := decoded.RemoveFirst()
+						if err != nil {
+							t.Fatal(err)
+						}
+						if x != i {
+							t.Fatalf("expected %d got %d", i, x)
+						}
+					}
+					decoded.AddLast(n)
+					if x, _ := decoded.PeekLast(); x != n {
+						t.Fatalf("expected %d got %d", n, x)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestQueue_EncodingJSONArray(t *testing.T) {
+	q := NewArrayQueue[int]()
+	q.AddAll(1, 2, 3)
+	data, err := json.Marshal(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "[1,2,3]" {
+		t.Fatalf("expected %s got %s", "[1,2,3]", data)
+	}
+	empty, err := json.Marshal(NewLinkedQueue[int]())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(empty) != "[]" {
+		t.Fatalf("expected %s got %s", "[]", empty)
+	}
+}
+
+func TestHeap_Encoding(t *testing.T) {
+	for _, codec := range createCodecs() {
+		t.Run(codec.name, func(t *testing.T) {
+			heap := NewHeap[int](0)
+			input := []int{5, 3, 8, 1, 9, 2, 7}
+			for _, x := range input {
+				heap.Add(x)
+			}
+			data, err := codec.encode(heap)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decoded := NewHeap[int](0)
+			if err := codec.decode(data, decoded); err != nil {
+				t.Fatal(err)
+			}
+			expected := slices.Sorted(slices.Values(input))
+			if actual := slices.Collect(decoded.Drain()); !slices.Equal(expected, actual) {
+				t.Fatalf("expected %v got %v", expected, actual)
+			}
+
+			// the heap order is restored for a different compare function
+			reversed := NewHeapWithCompare[int](0, func(x, y int) int {
+				return -cmp.Compare(x, y)
+			})
+			if err := codec.decode(data, reversed); err != nil {
+				t.Fatal(err)
+			}
+			slices.Reverse(expected)
+			if actual := slices.Collect(reversed.Drain()); !slices.Equal(expected, actual) {
+				t.Fatalf("expected %v got %v", expected, actual)
+			}
+
+			if err := codec.decode(data, &Heap[int]{}); err == nil {
+				t.Fatalf("expected error decoding into a heap without compare function")
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package collections
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrQueueEmpty returned when an element is removed from or looked up in an empty queue.
+	ErrQueueEmpty = errors.New("queue is empty")
+	// ErrQueueFull returned when an element is added to a queue that has reached its capacity.
+	ErrQueueFull = errors.New("queue is full")
+	// ErrClosed returned by operations on a queue that has been closed.
+	ErrClosed = errors.New("queue is closed")
+	// ErrExceedsCapacity returned when an operation needs more space or elements than a bounded queue can ever hold.
+	ErrExceedsCapacity = errors.New("exceeds queue capacity")
+	// ErrDropped returned by batch adds when the overflow policy has dropped some of the elements instead of adding
+	// them.
+	ErrDropped = errors.New("elements dropped")
+)
+
+// QueueError describes a failed queue operation. It wraps one of the sentinel errors, so it can be checked with
+// errors.Is, e.g. errors.Is(err, ErrQueueEmpty).
+type QueueError struct {
+	// Op name of the method that failed, e.g. "RemoveFirst".
+	Op string
+	// Capacity max number of elements of a bounded queue, 0 for unbounded queues.
+	Capacity uint
+	Err      error
+}
+
+func (e *QueueError) Error() string {
+	if e.Capacity > 0 {
+		return fmt.Sprintf("%s: %v (capacity %d)", e.Op, e.Err, e.Capacity)
+	}
+	return fmt.Sprintf("%s: %v", e.Op, e.Err)
+}
+
+func (e *QueueError) Unwrap() error {
+	return e.Err
+}
+
+// IndexOutOfRangeError returned when an element is accessed by an index outside [0, Size).
+type IndexOutOfRangeError struct {
+	Index uint
+	Size  uint
+}
+
+func (e *IndexOutOfRangeError) Error() string {
+	return fmt.Sprintf("index %d out of range [0, %d)", e.Index, e.Size)
+}
@@ -0,0 +1,221 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+)
+
+type fairSubQueue[T any] struct {
+	queue   *ArrayQueue[T]
+	deficit uint
+	// inTurn whether the key is being served in the current round and has already received its quantum
+	inTurn bool
+}
+
+// FairQueue an implementation of QueueWithLimit which keeps a separate queue for every key, e.g. a tenant, so that
+// one key cannot starve the others. Elements are removed using deficit round robin: keys with elements take turns,
+// and in every turn a key receives its quantum of credit, which it spends on removing elements until the cost of
+// the next one exceeds it. Elements of the same key are removed in FIFO order.
+//
+// Both the number of all elements and the number of elements of every key are limited, a producer blocks if either
+// of the limits has been reached.
+type FairQueue[K comparable, T any] struct {
+	key           func(T) K
+	cost          func(T) uint
+	maxSize       uint
+	maxSizePerKey uint
+	lock          sync.Mutex
+	quanta        map[K]uint
+	queues        map[K]*fairSubQueue[T]
+	// active keys with elements in round robin order, the first one is being served
+	active   *ArrayQueue[K]
+	size     uint
+	notEmpty *signal
+	notFull  *signal
+}
+
+// NewFairQueue creates a queue where every element costs 1 and every key has quantum 1 by default, so keys take
+// turns removing a single element.
+func NewFairQueue[K comparable, T any](maxSize, maxSizePerKey uint, key func(T) K) (*FairQueue[K, T], error) {
+	return NewFairQueueWithCost(maxSize, maxSizePerKey, key, func(T) uint { return 1 })
+}
+
+// NewFairQueueWithCost creates a queue where elements have the given cost, e.g. their size in bytes, to be compared
+// with quanta of keys.
+func NewFairQueueWithCost[K comparable, T any](maxSize, maxSizePerKey uint, key func(T) K, cost func(T) uint) (*FairQueue[K, T], error) {
+	if maxSize == 0 || maxSizePerKey == 0 {
+		return nil, errors.New("maxSize and maxSizePerKey must be positive")
+	}
+	return &FairQueue[K, T]{
+		key:           key,
+		cost:          cost,
+		maxSize:       maxSize,
+		maxSizePerKey: maxSizePerKey,
+		quanta:        make(map[K]uint),
+		queues:        make(map[K]*fairSubQueue[T]),
+		active:        NewArrayQueue[K](),
+		notEmpty:      newSignal(),
+		notFull:       newSignal(),
+	}, nil
+}
+
+// SetQuantum sets the credit the key receives in every turn, i.e. its weight. Zero restores the default of 1.
+func (q *FairQueue[K, T]) SetQuantum(key K, quantum uint) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if quantum == 0 {
+		delete(q.quanta, key)
+	} else {
+		q.quanta[key] = quantum
+	}
+}
+
+func (q *FairQueue[K, T]) AddLast(ctx context.Context, t T) error {
+	key := q.key(t)
+	return await(ctx, q.notFull, 0, func() bool {
+		return q.tryAddLast(key, t) == nil
+	})
+}
+
+func (q *FairQueue[K, T]) TryAddLast(t T) error {
+	return q.tryAddLast(q.key(t), t)
+}
+
+func (q *FairQueue[K, T]) RemoveFirst(ctx context.Context) (t T, err error) {
+	err = await(ctx, q.notEmpty, 0, func() (ok bool) {
+		t, ok = q.tryRemoveFirst()
+		return ok
+	})
+	return t, err
+}
+
+func (q *FairQueue[K, T]) TryRemoveFirst() (T, error) {
+	t, ok := q.tryRemoveFirst()
+	if !ok {
+		return t, &QueueError{Op: "TryRemoveFirst", Capacity: q.maxSize, Err: ErrQueueEmpty}
+	}
+	return t, nil
+}
+
+func (q *FairQueue[K, T]) MaxSize() uint {
+	return q.maxSize
+}
+
+// MaxSizePerKey returns max number of elements of a single key this queue can store.
+func (q *FairQueue[K, T]) MaxSizePerKey() uint {
+	return q.maxSizePerKey
+}
+
+func (q *FairQueue[K, T]) Size() uint {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.size
+}
+
+// SizeOf returns the number of elements of the given key.
+func (q *FairQueue[K, T]) SizeOf(key K) uint {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if sub, ok := q.queues[key]; ok {
+		return sub.queue.Size()
+	}
+	return 0
+}
+
+func (q *FairQueue[K, T]) tryAddLast(key K, t T) error {
+	q.lock.Lock()
+	if q.size >= q.maxSize {
+		q.lock.Unlock()
+		return &QueueError{Op: "TryAddLast", Capacity: q.maxSize, Err: ErrQueueFull}
+	}
+	sub, ok := q.queues[key]
+	if !ok {
+		sub = &fairSubQueue[T]{queue: NewArrayQueue[T]()}
+		q.queues[key] = sub
+		q.active.AddLast(key)
+	}
+	if sub.queue.Size() >= q.maxSizePerKey {
+		q.lock.Unlock()
+		return &QueueError{Op: "TryAddLast", Capacity: q.maxSizePerKey, Err: ErrQueueFull}
+	}
+	sub.queue.AddLast(t)
+	q.size++
+	q.lock.Unlock()
+	q.notEmpty.notify()
+	return nil
+}
+
+func (q *FairQueue[K, T]) tryRemoveFirst() (t T, ok bool) {
+	q.lock.Lock()
+	if q.size == 0 {
+		q.lock.Unlock()
+		return t, false
+	}
+	// turns in a row in which no element has been removed, after a whole round of them the rounds in which no key
+	// can afford its first element are skipped at once
+	idle := uint(0)
+	for {
+		if idle == q.active.Size() {
+			q.skipIdleRounds()
+			idle = 0
+		}
+		key, _ := q.active.PeekFirst()
+		sub := q.queues[key]
+		if !sub.inTurn {
+			sub.inTurn = true
+			sub.deficit += q.quantum(key)
+		}
+		first, _ := sub.queue.PeekFirst()
+		if cost := q.cost(first); cost <= sub.deficit {
+			t, _ = sub.queue.RemoveFirst()
+			sub.deficit -= cost
+			q.size--
+			if sub.queue.Size() == 0 {
+				// a key without elements does not keep its credit
+				delete(q.queues, key)
+				_, _ = q.active.RemoveFirst()
+			}
+			break
+		}
+		// the turn is over, the remaining credit is kept for the next one
+		sub.inTurn = false
+		idle++
+		_, _ = q.active.RemoveFirst()
+		q.active.AddLast(key)
+	}
+	q.lock.Unlock()
+	q.notFull.notify()
+	return t, true
+}
+
+// skipIdleRounds gives every active key the credit of all the rounds before the first one in which some key can
+// afford its first element, as if they had been played. It must be called at the start of a round.
+func (q *FairQueue[K, T]) skipIdleRounds() {
+	rounds := uint(math.MaxUint)
+	for key := range q.active.All() {
+		rounds = min(rounds, q.roundsNeeded(key))
+	}
+	for key := range q.active.All() {
+		q.queues[key].deficit += (rounds - 1) * q.quantum(key)
+	}
+}
+
+// roundsNeeded returns the number of rounds, at least 1, after which the key can afford its first element.
+func (q *FairQueue[K, T]) roundsNeeded(key K) uint {
+	sub := q.queues[key]
+	first, _ := sub.queue.PeekFirst()
+	cost := q.cost(first)
+	if cost <= sub.deficit {
+		return 1
+	}
+	return (cost-sub.deficit-1)/q.quantum(key) + 1
+}
+
+func (q *FairQueue[K, T]) quantum(key K) uint {
+	if quantum, ok := q.quanta[key]; ok {
+		return quantum
+	}
+	return 1
+}
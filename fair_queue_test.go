@@ -0,0 +1,220 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type tenantJob struct {
+	tenant string
+	id     int
+}
+
+func newTenantQueue(t *testing.T, maxSize, maxSizePerKey uint) *FairQueue[string, tenantJob] {
+	q, err := NewFairQueue[string, tenantJob](maxSize, maxSizePerKey, func(j tenantJob) string {
+		return j.tenant
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func removeTenants(t *testing.T, q *FairQueue[string, tenantJob], n int) string {
+	var tenants []string
+	for i := 0; i < n; i++ {
+		j, err := q.TryRemoveFirst()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tenants = append(tenants, j.tenant)
+	}
+	return strings.Join(tenants, "")
+}
+
+func TestFairQueue_RoundRobin(t *testing.T) {
+	q := newTenantQueue(t, 100, 100)
+	for i := 0; i < 5; i++ {
+		if err := q.TryAddLast(tenantJob{"a", i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tenant := range []string{"b", "c", "b"} {
+		if err := q.TryAddLast(tenantJob{tenant, 0}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if order := removeTenants(t, q, 8); order != "abcabaaa" {
+		t.Fatalf("expected %s got %s", "abcabaaa", order)
+	}
+	if _, err := q.TryRemoveFirst(); !errors.Is(err, ErrQueueEmpty) {
+		t.Fatalf("expected queue is empty error, got %v", err)
+	}
+}
+
+func TestFairQueue_FIFOPerKey(t *testing.T) {
+	q := newTenantQueue(t, 100, 100)
+	for i := 0; i < 10; i++ {
+		if err := q.TryAddLast(tenantJob{[]string{"a", "b"}[i%3%2], i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids := map[string][]int{}
+	for q.Size() > 0 {
+		j, err := q.TryRemoveFirst()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[j.tenant] = append(ids[j.tenant], j.id)
+	}
+	for tenant, tenantIds := range ids {
+		if !slices.IsSorted(tenantIds) {
+			t.Fatalf("expected elements of %s in FIFO order, got %v", tenant, tenantIds)
+		}
+	}
+}
+
+func TestFairQueue_Quantum(t *testing.T) {
+	q := newTenantQueue(t, 100, 100)
+	q.SetQuantum("a", 3)
+	for i := 0; i < 6; i++ {
+		for _, tenant := range []string{"a", "b"} {
+			if err := q.TryAddLast(tenantJob{tenant, i}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if order := removeTenants(t, q, 12); order != "aaabaaabbbbb" {
+		t.Fatalf("expected %s got %s", "aaabaaabbbbb", order)
+	}
+}
+
+func TestFairQueue_Cost(t *testing.T) {
+	// elements cost their length, so a tenant with twice as long elements gets half as many of them removed
+	q, err := NewFairQueueWithCost[byte, string](100, 100, func(s string) byte {
+		return s[0]
+	}, func(s string) uint {
+		return uint(len(s))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.SetQuantum('l', 4)
+	q.SetQuantum('s', 4)
+	for i := 0; i < 10; i++ {
+		for _, s := range []string{"llll", "ss"} {
+			if err := q.TryAddLast(s); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	var order []byte
+	for i := 0; i < 9; i++ {
+		s, err := q.TryRemoveFirst()
+		if err != nil {
+			t.Fatal(err)
+		}
+		order = append(order, s[0])
+	}
+	if string(order) != "lsslsslss" {
+		t.Fatalf("expected %s got %s", "lsslsslss", order)
+	}
+}
+
+func TestFairQueue_Limits(t *testing.T) {
+	q := newTenantQueue(t, 3, 2)
+	for i := 0; i < 2; i++ {
+		if err := q.TryAddLast(tenantJob{"a", i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.TryAddLast(tenantJob{"a", 2}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected queue is full error, got %v", err)
+	}
+	if err := q.TryAddLast(tenantJob{"b", 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.TryAddLast(tenantJob{"c", 0}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected queue is full error, got %v", err)
+	}
+	if q.SizeOf("a") != 2 || q.SizeOf("c") != 0 {
+		t.Fatalf("expected sizes %d and %d got %d and %d", 2, 0, q.SizeOf("a"), q.SizeOf("c"))
+	}
+
+	ctx := context.Background()
+	errs := make(chan error)
+	go func() {
+		errs <- q.AddLast(ctx, tenantJob{"a", 2})
+	}()
+	// the producer is blocked by the limit of tenant a, until one of its elements is removed
+	if j, err := q.TryRemoveFirst(); err != nil || j.tenant != "a" {
+		t.Fatalf("expected an element of %s got %v %v", "a", j, err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := q.AddLast(timeout, tenantJob{"a", 3}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded error, got %v", err)
+	}
+}
+
+func TestFairQueue_LargeCost(t *testing.T) {
+	// with quantum 1 the expensive elements need billions of rounds, which are skipped instead of played
+	q, err := NewFairQueueWithCost[string, tenantJob](100, 100, func(j tenantJob) string {
+		return j.tenant
+	}, func(j tenantJob) uint {
+		if j.tenant == "big" {
+			return 1_000_000_000
+		}
+		return 1
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tenant := range []string{"big", "small", "small", "small", "big"} {
+		if err := q.TryAddLast(tenantJob{tenant, 0}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var order []string
+	for q.Size() > 0 {
+		j, err := q.TryRemoveFirst()
+		if err != nil {
+			t.Fatal(err)
+		}
+		order = append(order, j.tenant)
+	}
+	expected := []string{"small", "small", "small", "big", "big"}
+	if !slices.Equal(order, expected) {
+		t.Fatalf("expected %v got %v", expected, order)
+	}
+}
+
+func TestFairQueue_SkipIdleRounds(t *testing.T) {
+	// a needs 3 rounds and b needs 2 rounds for its first element, so b goes first although a is ahead of it
+	q, err := NewFairQueueWithCost[string, tenantJob](100, 100, func(j tenantJob) string {
+		return j.tenant
+	}, func(j tenantJob) uint {
+		return uint(j.id)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.SetQuantum("a", 100)
+	q.SetQuantum("b", 1000)
+	for _, j := range []tenantJob{{"a", 250}, {"b", 1500}, {"a", 50}} {
+		if err := q.TryAddLast(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if order := removeTenants(t, q, 3); order != "baa" {
+		t.Fatalf("expected %s got %s", "baa", order)
+	}
+}
@@ -3,14 +3,16 @@ package collections
 import (
 	"cmp"
 	"errors"
+	"iter"
 )
 
 var ErrEmptyHeap = errors.New("heap is empty")
 
 type Heap[T any] struct {
-	array   []T
-	size    int
-	compare func(T, T) int
+	array        []T
+	size         int
+	compare      func(T, T) int
+	shrinkPolicy ShrinkPolicy
 }
 
 func NewHeap[T cmp.Ordered](initialCapacity int) *Heap[T] {
@@ -56,11 +58,73 @@ func (heap *Heap[T]) Remove() (t T, err error) {
 	if heap.size == 0 {
 		return t, ErrEmptyHeap
 	}
-	element := heap.array[0]
+	return heap.removeAt(0), nil
+}
+
+// removeAt removes the element at the given index of the underlying array.
+func (heap *Heap[T]) removeAt(index int) T {
+	element := heap.array[index]
 	heap.size -= 1
-	swap(heap.array, 0, heap.size)
-	heap.siftDown(heap.array, 0, heap.size-1)
-	return element, nil
+	swap(heap.array, index, heap.size)
+	var zero T
+	heap.array[heap.size] = zero
+	if index < heap.size {
+		heap.siftDown(heap.array, index, heap.size-1)
+		heap.siftUp(heap.array, index)
+	}
+	heap.shrinkIfNeeded()
+	return element
+}
+
+// SetShrinkPolicy sets the policy deciding when the backing array is reallocated to a smaller one after removals.
+// A nil policy never shrinks.
+func (heap *Heap[T]) SetShrinkPolicy(policy ShrinkPolicy) {
+	heap.shrinkPolicy = policy
+}
+
+// TrimToSize reallocates the backing array so that its capacity equals the number of elements in the heap.
+func (heap *Heap[T]) TrimToSize() {
+	heap.resize(heap.size)
+}
+
+func (heap *Heap[T]) shrinkIfNeeded() {
+	if heap.shrinkPolicy == nil {
+		return
+	}
+	capacity := uint(cap(heap.array))
+	if newCapacity := heap.shrinkPolicy(uint(heap.size), capacity); newCapacity < capacity {
+		heap.resize(max(int(newCapacity), heap.size))
+	}
+}
+
+func (heap *Heap[T]) resize(capacity int) {
+	array := make([]T, heap.size, capacity)
+	copy(array, heap.array[:heap.size])
+	heap.array = array
+}
+
+// All returns an iterator over elements of the heap in the order they are stored in the underlying array.
+// Only the first element is guaranteed to be in its final position, the rest follow the heap order.
+func (heap *Heap[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := 0; i < heap.size; i++ {
+			if !yield(heap.array[i]) {
+				return
+			}
+		}
+	}
+}
+
+// Drain returns an iterator that removes elements from the heap as it yields them, in sorted order.
+func (heap *Heap[T]) Drain() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for heap.size > 0 {
+			t, _ := heap.Remove()
+			if !yield(t) {
+				return
+			}
+		}
+	}
 }
 
 func (heap *Heap[T]) siftUp(array []T, index int) {
@@ -97,6 +161,13 @@ func (heap *Heap[T]) siftDown(array []T, i int, lastIndex int) {
 	}
 }
 
+// heapify restores the heap order of the whole array in linear time, using Floyd's method.
+func (heap *Heap[T]) heapify() {
+	for i := heap.size/2 - 1; i >= 0; i-- {
+		heap.siftDown(heap.array, i, heap.size-1)
+	}
+}
+
 func swap[K any](array []K, index int, index2 int) {
 	array[index], array[index2] = array[index2], array[index]
 }
@@ -3,6 +3,7 @@ package collections
 import (
 	"cmp"
 	"math/rand"
+	"slices"
 	"sort"
 	"testing"
 )
@@ -168,6 +169,31 @@ func TestHeapWithStandardComparison_Duplicated(t *testing.T) {
 	}
 }
 
+func TestHeap_Iterators(t *testing.T) {
+	var n = 100
+	var input = randomIntArray(n)
+	var heap = NewHeap[int](0)
+	for _, x := range input {
+		heap.Add(x)
+	}
+	all := slices.Collect(heap.All())
+	if !slices.Equal(sorted(all), orderedIntArray(n)) {
+		t.Fatalf("expected all elements of the heap, got %v, input: %v", all, input)
+	}
+	if all[0] != 0 {
+		t.Fatalf("expected first element to be 0, got %d, input: %v", all[0], input)
+	}
+	if heap.Size() != n {
+		t.Fatalf("expected heap size to be %d, got %d", n, heap.Size())
+	}
+	if drained := slices.Collect(heap.Drain()); !slices.Equal(drained, orderedIntArray(n)) {
+		t.Fatalf("expected drained elements to be sorted, got %v, input: %v", drained, input)
+	}
+	if !heap.IsEmpty() {
+		t.Fatalf("heap is not empty, input: %v", input)
+	}
+}
+
 func orderedIntArray(n int) []int {
 	result := make([]int, n)
 	for i := 0; i < n; i++ {
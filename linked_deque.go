@@ -0,0 +1,132 @@
+package collections
+
+import (
+	"iter"
+)
+
+type doublyLinkedEntry[T any] struct {
+	value T
+	prev  *doublyLinkedEntry[T]
+	next  *doublyLinkedEntry[T]
+}
+
+// LinkedDeque a deque based on double-linked list. This implementation is not threadsafe.
+type LinkedDeque[T any] struct {
+	head *doublyLinkedEntry[T]
+	tail *doublyLinkedEntry[T]
+	size uint
+}
+
+func NewLinkedDeque[T any]() *LinkedDeque[T] {
+	return &LinkedDeque[T]{}
+}
+
+func (q *LinkedDeque[T]) AddFirst(value T) {
+	e := &doublyLinkedEntry[T]{value: value, next: q.head}
+	if q.head != nil {
+		q.head.prev = e
+	} else {
+		q.tail = e
+	}
+	q.head = e
+	q.size++
+}
+
+func (q *LinkedDeque[T]) AddLast(value T) {
+	e := &doublyLinkedEntry[T]{value: value, prev: q.tail}
+	if q.tail != nil {
+		q.tail.next = e
+	} else {
+		q.head = e
+	}
+	q.tail = e
+	q.size++
+}
+
+func (q *LinkedDeque[T]) RemoveFirst() (T, error) {
+	if q.head == nil {
+		var t T
+		return t, &QueueError{Op: "RemoveFirst", Err: ErrQueueEmpty}
+	}
+	e := q.head
+	q.head = e.next
+	if q.head != nil {
+		q.head.prev = nil
+	} else {
+		q.tail = nil
+	}
+	e.next = nil
+	q.size--
+	return e.value, nil
+}
+
+func (q *LinkedDeque[T]) RemoveLast() (T, error) {
+	if q.tail == nil {
+		var t T
+		return t, &QueueError{Op: "RemoveLast", Err: ErrQueueEmpty}
+	}
+	e := q.tail
+	q.tail = e.prev
+	if q.tail != nil {
+		q.tail.next = nil
+	} else {
+		q.head = nil
+	}
+	e.prev = nil
+	q.size--
+	return e.value, nil
+}
+
+func (q *LinkedDeque[T]) PeekFirst() (T, error) {
+	if q.head == nil {
+		var t T
+		return t, &QueueError{Op: "PeekFirst", Err: ErrQueueEmpty}
+	}
+	return q.head.value, nil
+}
+
+func (q *LinkedDeque[T]) PeekLast() (T, error) {
+	if q.tail == nil {
+		var t T
+		return t, &QueueError{Op: "PeekLast", Err: ErrQueueEmpty}
+	}
+	return q.tail.value, nil
+}
+
+func (q *LinkedDeque[T]) Size() uint {
+	return q.size
+}
+
+// All returns an iterator over elements of the deque from the first to the last one without removing them.
+func (q *LinkedDeque[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for e := q.head; e != nil; e = e.next {
+			if !yield(e.value) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over elements of the deque from the last to the first one without removing them.
+func (q *LinkedDeque[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for e := q.tail; e != nil; e = e.prev {
+			if !yield(e.value) {
+				return
+			}
+		}
+	}
+}
+
+// Drain returns an iterator that removes elements from the front of the deque as it yields them.
+func (q *LinkedDeque[T]) Drain() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for q.size > 0 {
+			t, _ := q.RemoveFirst()
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
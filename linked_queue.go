@@ -1,7 +1,7 @@
 package collections
 
 import (
-	"errors"
+	"iter"
 )
 
 type entry[T any] struct {
@@ -31,10 +31,24 @@ func (q *LinkedQueue[T]) AddLast(value T) {
 	q.size++
 }
 
+// AddAll adds elements to the end of the queue.
+func (q *LinkedQueue[T]) AddAll(values ...T) {
+	for _, value := range values {
+		q.AddLast(value)
+	}
+}
+
+// AddSeq adds all elements of the sequence to the end of the queue.
+func (q *LinkedQueue[T]) AddSeq(seq iter.Seq[T]) {
+	for value := range seq {
+		q.AddLast(value)
+	}
+}
+
 func (q *LinkedQueue[T]) RemoveFirst() (T, error) {
 	if q.head == nil {
 		var t T
-		return t, errors.New("the queue is empty")
+		return t, &QueueError{Op: "RemoveFirst", Err: ErrQueueEmpty}
 	}
 	e := q.head
 	q.head = e.next
@@ -45,6 +59,62 @@ func (q *LinkedQueue[T]) RemoveFirst() (T, error) {
 	return e.value, nil
 }
 
+// RemoveN removes up to n first elements from the queue and returns them.
+func (q *LinkedQueue[T]) RemoveN(n int) []T {
+	result := make([]T, max(0, min(n, int(q.size))))
+	q.RemoveInto(result)
+	return result
+}
+
+// RemoveInto removes first elements from the queue into dst until either dst is full or the queue is empty.
+// Returns the number of removed elements.
+func (q *LinkedQueue[T]) RemoveInto(dst []T) int {
+	n := 0
+	for ; n < len(dst) && q.head != nil; n++ {
+		dst[n], _ = q.RemoveFirst()
+	}
+	return n
+}
+
+func (q *LinkedQueue[T]) PeekFirst() (T, error) {
+	if q.head == nil {
+		var t T
+		return t, &QueueError{Op: "PeekFirst", Err: ErrQueueEmpty}
+	}
+	return q.head.value, nil
+}
+
+func (q *LinkedQueue[T]) PeekLast() (T, error) {
+	if q.tail == nil {
+		var t T
+		return t, &QueueError{Op: "PeekLast", Err: ErrQueueEmpty}
+	}
+	return q.tail.value, nil
+}
+
 func (q *LinkedQueue[T]) Size() uint {
 	return q.size
 }
+
+// All returns an iterator over elements of the queue from the first to the last one without removing them.
+func (q *LinkedQueue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for e := q.head; e != nil; e = e.next {
+			if !yield(e.value) {
+				return
+			}
+		}
+	}
+}
+
+// Drain returns an iterator that removes elements from the queue as it yields them.
+func (q *LinkedQueue[T]) Drain() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for q.size > 0 {
+			t, _ := q.RemoveFirst()
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
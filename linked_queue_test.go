@@ -1,6 +1,7 @@
 package collections
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -11,6 +12,52 @@ func TestLinkedQueue(t *testing.T) {
 	emptyQueue(queue, n, t)
 }
 
+func TestLinkedQueue_Iterators(t *testing.T) {
+	var queue = NewLinkedQueue[int]()
+	var n uint = 10
+	fillQueue(queue, n, t)
+	if all := slices.Collect(queue.All()); !slices.Equal(all, orderedIntArray(int(n))) {
+		t.Fatalf("expected %v got %v", orderedIntArray(int(n)), all)
+	}
+	if queue.Size() != n {
+		t.Fatalf("expected %d got %d", n, queue.Size())
+	}
+	for x := range queue.Drain() {
+		if x == 4 {
+			break
+		}
+	}
+	if queue.Size() != n-5 {
+		t.Fatalf("expected %d got %d", n-5, queue.Size())
+	}
+	if drained := slices.Collect(queue.Drain()); !slices.Equal(drained, []int{5, 6, 7, 8, 9}) {
+		t.Fatalf("expected %v got %v", []int{5, 6, 7, 8, 9}, drained)
+	}
+	if queue.Size() != 0 {
+		t.Fatalf("expected %d got %d", 0, queue.Size())
+	}
+}
+
+func TestLinkedQueue_Peek(t *testing.T) {
+	var queue = NewLinkedQueue[int]()
+	if _, err := queue.PeekFirst(); err == nil {
+		t.Fatalf("expected error when peeking into an empty queue")
+	}
+	if _, err := queue.PeekLast(); err == nil {
+		t.Fatalf("expected error when peeking into an empty queue")
+	}
+	fillQueue(queue, 10, t)
+	if first, err := queue.PeekFirst(); err != nil || first != 0 {
+		t.Fatalf("expected %d got %d, %v", 0, first, err)
+	}
+	if last, err := queue.PeekLast(); err != nil || last != 9 {
+		t.Fatalf("expected %d got %d, %v", 9, last, err)
+	}
+	if queue.Size() != 10 {
+		t.Fatalf("expected %d got %d", 10, queue.Size())
+	}
+}
+
 func fillQueue(queue *LinkedQueue[int], n uint, t *testing.T) {
 	for i := 0; i < int(n); i++ {
 		queue.AddLast(i)
@@ -0,0 +1,148 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+)
+
+type lockFreeSlot[T any] struct {
+	sequence atomic.Uint64
+	value    T
+}
+
+// LockFreeQueueWithLimit an implementation of QueueWithLimit based on the bounded multi-producer multi-consumer
+// array queue by Dmitry Vyukov. Each slot carries a sequence number telling whether it is ready to be written
+// or read at the given position, so producers and consumers only compete on a single CAS of their position.
+// Sequence numbers are doubled so that "written at pos" (2*pos+1) never collides with "free at pos+maxSize"
+// (2*(pos+maxSize)), which would otherwise happen for a queue of size one.
+// Blocking operations spin for a while and then park until the opposite side makes progress.
+type LockFreeQueueWithLimit[T any] struct {
+	_          cacheLinePad
+	enqueuePos atomic.Uint64
+	_          cacheLinePad
+	dequeuePos atomic.Uint64
+	_          cacheLinePad
+	slots      []lockFreeSlot[T]
+	maxSize    uint64
+	notEmpty   *signal
+	notFull    *signal
+}
+
+func NewLockFreeQueueWithLimit[T any](maxSize uint) (*LockFreeQueueWithLimit[T], error) {
+	if maxSize == 0 {
+		return nil, errors.New("maxSize must be positive")
+	}
+	slots := make([]lockFreeSlot[T], maxSize)
+	for i := range slots {
+		slots[i].sequence.Store(2 * uint64(i))
+	}
+	return &LockFreeQueueWithLimit[T]{
+		slots:    slots,
+		maxSize:  uint64(maxSize),
+		notEmpty: newSignal(),
+		notFull:  newSignal(),
+	}, nil
+}
+
+func (q *LockFreeQueueWithLimit[T]) AddLast(ctx context.Context, t T) error {
+	return await(ctx, q.notFull, spinCount, func() bool {
+		return q.tryAddLast(t)
+	})
+}
+
+func (q *LockFreeQueueWithLimit[T]) TryAddLast(t T) error {
+	if !q.tryAddLast(t) {
+		return &QueueError{Op: "TryAddLast", Capacity: q.MaxSize(), Err: ErrQueueFull}
+	}
+	return nil
+}
+
+func (q *LockFreeQueueWithLimit[T]) RemoveFirst(ctx context.Context) (t T, err error) {
+	err = await(ctx, q.notEmpty, spinCount, func() (ok bool) {
+		t, ok = q.tryRemoveFirst()
+		return ok
+	})
+	return t, err
+}
+
+func (q *LockFreeQueueWithLimit[T]) TryRemoveFirst() (T, error) {
+	t, ok := q.tryRemoveFirst()
+	if !ok {
+		return t, &QueueError{Op: "TryRemoveFirst", Capacity: q.MaxSize(), Err: ErrQueueEmpty}
+	}
+	return t, nil
+}
+
+func (q *LockFreeQueueWithLimit[T]) MaxSize() uint {
+	return uint(q.maxSize)
+}
+
+// Size returns an approximate number of elements, as it may change while it is being computed.
+func (q *LockFreeQueueWithLimit[T]) Size() uint {
+	dequeuePos := q.dequeuePos.Load()
+	enqueuePos := q.enqueuePos.Load()
+	if enqueuePos <= dequeuePos {
+		return 0
+	}
+	return uint(min(enqueuePos-dequeuePos, q.maxSize))
+}
+
+func (q *LockFreeQueueWithLimit[T]) tryAddLast(t T) bool {
+	pos := q.enqueuePos.Load()
+	for {
+		slot := &q.slots[pos%q.maxSize]
+		sequence := slot.sequence.Load()
+		switch diff := int64(sequence - 2*pos); {
+		case diff == 0:
+			if q.enqueuePos.CompareAndSwap(pos, pos+1) {
+				slot.value = t
+				slot.sequence.Store(2*pos + 1)
+				q.notEmpty.notify()
+				return true
+			}
+			pos = q.enqueuePos.Load()
+		case diff < 0:
+			// the slot still holds an element from the previous lap
+			if q.dequeuePos.Load()+q.maxSize <= pos {
+				return false
+			}
+			// a consumer has claimed it but not released it yet, so the queue is not full
+			runtime.Gosched()
+			pos = q.enqueuePos.Load()
+		default:
+			pos = q.enqueuePos.Load()
+		}
+	}
+}
+
+func (q *LockFreeQueueWithLimit[T]) tryRemoveFirst() (t T, ok bool) {
+	pos := q.dequeuePos.Load()
+	for {
+		slot := &q.slots[pos%q.maxSize]
+		sequence := slot.sequence.Load()
+		switch diff := int64(sequence - (2*pos + 1)); {
+		case diff == 0:
+			if q.dequeuePos.CompareAndSwap(pos, pos+1) {
+				var zero T
+				t = slot.value
+				slot.value = zero
+				slot.sequence.Store(2 * (pos + q.maxSize))
+				q.notFull.notify()
+				return t, true
+			}
+			pos = q.dequeuePos.Load()
+		case diff < 0:
+			// the slot has not been written in this lap yet
+			if q.enqueuePos.Load() <= pos {
+				return t, false
+			}
+			// a producer has claimed it but not published it yet, so the queue is not empty
+			runtime.Gosched()
+			pos = q.dequeuePos.Load()
+		default:
+			pos = q.dequeuePos.Load()
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package collections
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLockFreeQueueWithLimit_SizeOne(t *testing.T) {
+	q, err := NewLockFreeQueueWithLimit[int](1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if err := q.TryAddLast(i); err != nil {
+			t.Fatal(err)
+		}
+		if err := q.TryAddLast(-1); !errors.Is(err, ErrQueueFull) {
+			t.Fatalf("expected queue is full error, got %v", err)
+		}
+		x, err := q.TryRemoveFirst()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != i {
+			t.Fatalf("expected %d got %d", i, x)
+		}
+		if _, err := q.TryRemoveFirst(); !errors.Is(err, ErrQueueEmpty) {
+			t.Fatalf("expected queue is empty error, got %v", err)
+		}
+	}
+}
+
+func TestLockFreeQueueWithLimit_ClaimedSlot(t *testing.T) {
+	q, err := NewLockFreeQueueWithLimit[int](2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a producer claimed position 0 but has not published its element yet
+	q.enqueuePos.Store(1)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.slots[0].value = 42
+		q.slots[0].sequence.Store(1)
+	}()
+	x, err := q.TryRemoveFirst()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x != 42 {
+		t.Fatalf("expected %d got %d", 42, x)
+	}
+
+	// a consumer claimed position 1 of a full queue but has not released its slot yet
+	q.enqueuePos.Store(3)
+	q.slots[1].sequence.Store(3)
+	q.slots[0].sequence.Store(5)
+	q.dequeuePos.Store(2)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.slots[1].sequence.Store(6)
+	}()
+	if err := q.TryAddLast(7); err != nil {
+		t.Fatal(err)
+	}
+}
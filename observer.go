@@ -0,0 +1,164 @@
+package collections
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Observer receives events of a queue, e.g. to collect metrics or to find out why a pipeline stalls. Its methods are
+// called synchronously by the goroutines using the queue, so they must be fast and threadsafe.
+type Observer interface {
+	// OnEnqueue called after n elements have been added, size is the number of elements right after that.
+	OnEnqueue(n, size uint)
+
+	// OnDequeue called after n elements have been removed, size is the number of elements right after that.
+	OnDequeue(n, size uint)
+
+	// OnBlock called when the operation op, e.g. "AddLast", starts waiting.
+	OnBlock(op string)
+
+	// OnUnblock called when the operation op stops waiting, whether it has succeeded or not.
+	OnUnblock(op string, wait time.Duration)
+
+	// OnReject called when the operation op fails to add elements, e.g. because the queue is full or closed.
+	OnReject(op string, err error)
+}
+
+// observeWait runs wait, reporting it as blocking to the observer if there is one.
+func observeWait(observer Observer, op string, wait func() error) error {
+	if observer == nil {
+		return wait()
+	}
+	start := time.Now()
+	observer.OnBlock(op)
+	err := wait()
+	observer.OnUnblock(op, time.Since(start))
+	return err
+}
+
+// observeReject reports a failed add to the observer, unless it has failed because its context is done, and returns
+// err.
+func observeReject(observer Observer, op string, err error) error {
+	var queueErr *QueueError
+	if observer != nil && errors.As(err, &queueErr) {
+		observer.OnReject(op, err)
+	}
+	return err
+}
+
+// waitBounds upper bounds of buckets of a WaitHistogram.
+var waitBounds = []time.Duration{
+	time.Microsecond,
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+}
+
+// WaitHistogram distribution of wait times. Counts[i] is the number of waits longer than Bounds[i-1] and not longer
+// than Bounds[i], the last count is the number of waits longer than all bounds.
+type WaitHistogram struct {
+	Bounds []time.Duration
+	Counts []uint64
+	Count  uint64
+	Total  time.Duration
+	Max    time.Duration
+}
+
+func (h *WaitHistogram) add(wait time.Duration) {
+	if h.Counts == nil {
+		h.Bounds = waitBounds
+		h.Counts = make([]uint64, len(waitBounds)+1)
+	}
+	i := 0
+	for i < len(h.Bounds) && wait > h.Bounds[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Count++
+	h.Total += wait
+	h.Max = max(h.Max, wait)
+}
+
+// QueueStats metrics collected by a StatsCollector.
+type QueueStats struct {
+	Enqueued uint64
+	Dequeued uint64
+	Rejected uint64
+	// Blocked number of operations waiting right now
+	Blocked int
+	// Size number of elements after the last add or remove
+	Size uint
+	// HighWaterMark the largest size seen so far
+	HighWaterMark uint
+	// Waits histograms of wait times by operation, e.g. "AddLast" or "RemoveFirst"
+	Waits map[string]WaitHistogram
+}
+
+// StatsCollector an Observer which counts events and measures wait times. It may observe many queues, the stats are
+// then combined.
+type StatsCollector struct {
+	lock  sync.Mutex
+	stats QueueStats
+}
+
+func NewStatsCollector() *StatsCollector {
+	return &StatsCollector{
+		stats: QueueStats{Waits: make(map[string]WaitHistogram)},
+	}
+}
+
+func (c *StatsCollector) OnEnqueue(n, size uint) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.stats.Enqueued += uint64(n)
+	c.stats.Size = size
+	c.stats.HighWaterMark = max(c.stats.HighWaterMark, size)
+}
+
+func (c *StatsCollector) OnDequeue(n, size uint) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.stats.Dequeued += uint64(n)
+	c.stats.Size = size
+}
+
+func (c *StatsCollector) OnBlock(string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.stats.Blocked++
+}
+
+func (c *StatsCollector) OnUnblock(op string, wait time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.stats.Blocked--
+	histogram := c.stats.Waits[op]
+	histogram.add(wait)
+	c.stats.Waits[op] = histogram
+}
+
+func (c *StatsCollector) OnReject(string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.stats.Rejected++
+}
+
+// Stats returns a copy of the stats collected so far.
+func (c *StatsCollector) Stats() QueueStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	stats := c.stats
+	stats.Waits = make(map[string]WaitHistogram, len(c.stats.Waits))
+	for op, histogram := range c.stats.Waits {
+		histogram.Bounds = slices.Clone(histogram.Bounds)
+		histogram.Counts = slices.Clone(histogram.Counts)
+		stats.Waits[op] = histogram
+	}
+	return stats
+}
@@ -0,0 +1,97 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+type observedQueue interface {
+	QueueWithLimit[int]
+	SetObserver(Observer)
+}
+
+func TestStatsCollector_Queues(t *testing.T) {
+	standard, err := NewArrayQueueWithLimit[int](2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		queue observedQueue
+	}{
+		{"standard queue", standard},
+		{"channelled queue", NewChannelledQueueWithLimit[int](2)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			collector := NewStatsCollector()
+			test.queue.SetObserver(collector)
+			ctx := context.Background()
+			for i := 0; i < 2; i++ {
+				if err := test.queue.AddLast(ctx, i); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := test.queue.TryAddLast(2); !errors.Is(err, ErrQueueFull) {
+				t.Fatalf("expected queue is full error, got %v", err)
+			}
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				_, _ = test.queue.TryRemoveFirst()
+			}()
+			if err := test.queue.AddLast(ctx, 2); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 2; i++ {
+				if _, err := test.queue.RemoveFirst(ctx); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// a timed out wait is reported too, but it is not a rejection
+			timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+			defer cancel()
+			if _, err := test.queue.RemoveFirst(timeout); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected deadline exceeded error, got %v", err)
+			}
+
+			stats := collector.Stats()
+			if stats.Enqueued != 3 || stats.Dequeued != 3 || stats.Rejected != 1 {
+				t.Fatalf("expected %d %d %d got %d %d %d", 3, 3, 1, stats.Enqueued, stats.Dequeued, stats.Rejected)
+			}
+			if stats.HighWaterMark != 2 {
+				t.Fatalf("expected %d got %d", 2, stats.HighWaterMark)
+			}
+			if stats.Size != 0 || stats.Blocked != 0 {
+				t.Fatalf("expected %d %d got %d %d", 0, 0, stats.Size, stats.Blocked)
+			}
+			add := stats.Waits["AddLast"]
+			if add.Count != 1 || add.Max < 10*time.Millisecond {
+				t.Fatalf("expected one wait of at least %v got %d up to %v", 10*time.Millisecond, add.Count, add.Max)
+			}
+			if remove := stats.Waits["RemoveFirst"]; remove.Count != 1 {
+				t.Fatalf("expected %d got %d", 1, remove.Count)
+			}
+		})
+	}
+}
+
+func TestWaitHistogram(t *testing.T) {
+	var histogram WaitHistogram
+	waits := []time.Duration{0, time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond, time.Minute}
+	for _, wait := range waits {
+		histogram.add(wait)
+	}
+	expected := []uint64{2, 0, 0, 0, 2, 0, 0, 0, 1}
+	if !slices.Equal(histogram.Counts, expected) {
+		t.Fatalf("expected %v got %v", expected, histogram.Counts)
+	}
+	if histogram.Count != 5 {
+		t.Fatalf("expected %d got %d", 5, histogram.Count)
+	}
+	if histogram.Max != time.Minute {
+		t.Fatalf("expected %v got %v", time.Minute, histogram.Max)
+	}
+}
@@ -0,0 +1,16 @@
+package collections
+
+// OverflowPolicy decides what StandardQueueWithLimit does with an element added to a full queue.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes AddLast wait for space and TryAddLast fail with ErrQueueFull. This is the default policy.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowReject makes AddLast fail with ErrQueueFull immediately, like TryAddLast.
+	OverflowReject
+	// OverflowDropOldest removes the oldest elements until there is space for the new element. A priority queue
+	// removes them in the order they have been added too, not in the order of priority.
+	OverflowDropOldest
+	// OverflowDropNewest drops the new element instead of adding it, the add succeeds.
+	OverflowDropNewest
+)
@@ -0,0 +1,78 @@
+package collections
+
+import (
+	"cmp"
+)
+
+// PriorityQueueWithLimit an implementation of QueueWithLimit which returns the first element according to
+// the compare function instead of the oldest one. Elements which compare as equal are returned in the order they
+// have been added. It supports all the operations of StandardQueueWithLimit, including closing and batches.
+type PriorityQueueWithLimit[T any] struct {
+	*StandardQueueWithLimit[T]
+}
+
+func NewPriorityQueueWithLimit[T cmp.Ordered](maxSize uint) (*PriorityQueueWithLimit[T], error) {
+	return NewPriorityQueueWithLimitWithCompare(maxSize, func(t1, t2 T) int {
+		return cmp.Compare(t1, t2)
+	})
+}
+
+func NewPriorityQueueWithLimitWithCompare[T any](maxSize uint, compare func(t1, t2 T) int) (*PriorityQueueWithLimit[T], error) {
+	q, err := newQueueWithLimit[T](maxSize, newHeapFifo(int(maxSize), compare))
+	if err != nil {
+		return nil, err
+	}
+	return &PriorityQueueWithLimit[T]{q}, nil
+}
+
+type sequenced[T any] struct {
+	value T
+	seq   uint64
+}
+
+// heapFifo adapts Heap to be used by StandardQueueWithLimit, adding a sequence number to every element to break
+// ties between equal ones.
+type heapFifo[T any] struct {
+	heap    *Heap[sequenced[T]]
+	nextSeq uint64
+}
+
+func newHeapFifo[T any](initialCapacity int, compare func(t1, t2 T) int) *heapFifo[T] {
+	return &heapFifo[T]{
+		heap: NewHeapWithCompare(initialCapacity, func(s1, s2 sequenced[T]) int {
+			if c := compare(s1.value, s2.value); c != 0 {
+				return c
+			}
+			return cmp.Compare(s1.seq, s2.seq)
+		}),
+	}
+}
+
+func (h *heapFifo[T]) AddLast(t T) {
+	h.heap.Add(sequenced[T]{value: t, seq: h.nextSeq})
+	h.nextSeq++
+}
+
+func (h *heapFifo[T]) RemoveFirst() (T, error) {
+	s, err := h.heap.Remove()
+	return s.value, err
+}
+
+func (h *heapFifo[T]) Size() uint {
+	return uint(h.heap.Size())
+}
+
+// RemoveOldest removes the element which has been added first, it takes linear time.
+func (h *heapFifo[T]) RemoveOldest() (T, error) {
+	if h.heap.IsEmpty() {
+		var zero T
+		return zero, ErrEmptyHeap
+	}
+	oldest := 0
+	for i := 1; i < h.heap.size; i++ {
+		if h.heap.array[i].seq < h.heap.array[oldest].seq {
+			oldest = i
+		}
+	}
+	return h.heap.removeAt(oldest).value, nil
+}
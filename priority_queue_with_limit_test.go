@@ -0,0 +1,146 @@
+package collections
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPriorityQueueWithLimit_Order(t *testing.T) {
+	q, err := NewPriorityQueueWithLimit[int](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	input := rand.Perm(100)
+	for _, x := range input {
+		if err := q.AddLast(ctx, x); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		x, err := q.RemoveFirst(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != i {
+			t.Fatalf("expected %d got %d", i, x)
+		}
+	}
+}
+
+func TestPriorityQueueWithLimit_EqualPriorities(t *testing.T) {
+	type job struct {
+		priority int
+		id       int
+	}
+	// higher priority first
+	q, err := NewPriorityQueueWithLimitWithCompare[job](100, func(j1, j2 job) int {
+		return -cmp.Compare(j1.priority, j2.priority)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id := 0; id < 100; id++ {
+		if err := q.TryAddLast(job{priority: id % 3, id: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var removed []job
+	for q.Size() > 0 {
+		j, err := q.TryRemoveFirst()
+		if err != nil {
+			t.Fatal(err)
+		}
+		removed = append(removed, j)
+	}
+	expected := slices.Clone(removed)
+	slices.SortStableFunc(expected, func(j1, j2 job) int {
+		if c := -cmp.Compare(j1.priority, j2.priority); c != 0 {
+			return c
+		}
+		return cmp.Compare(j1.id, j2.id)
+	})
+	if !slices.Equal(expected, removed) {
+		t.Fatalf("expected %v got %v", expected, removed)
+	}
+}
+
+func TestPriorityQueueWithLimit_DropOldest(t *testing.T) {
+	q, err := NewPriorityQueueWithLimit[int](10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.SetOverflowPolicy(OverflowDropOldest)
+	var dropped []int
+	q.SetOnDrop(func(x int) {
+		dropped = append(dropped, x)
+	})
+	ctx := context.Background()
+	input := rand.Perm(30)
+	for _, x := range input {
+		if err := q.AddLast(ctx, x); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the elements added first are dropped, not the ones with the highest priority
+	if !slices.Equal(dropped, input[:20]) {
+		t.Fatalf("expected %v got %v", input[:20], dropped)
+	}
+	expected := slices.Sorted(slices.Values(input[20:]))
+	for _, e := range expected {
+		x, err := q.RemoveFirst(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != e {
+			t.Fatalf("expected %d got %d", e, x)
+		}
+	}
+}
+
+func TestPriorityQueueWithLimit_Blocking(t *testing.T) {
+	q, err := NewPriorityQueueWithLimit[int](2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.RemoveFirst(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded error, got %v", err)
+	}
+	for _, x := range []int{5, 3} {
+		if err := q.AddLast(ctx, x); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.AddLast(timeout, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded error, got %v", err)
+	}
+
+	errs := make(chan error)
+	go func() {
+		errs <- q.AddLast(ctx, 1)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if x, err := q.RemoveFirst(ctx); err != nil || x != 3 {
+		t.Fatalf("expected %d got %d %v", 3, x, err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []int{1, 5} {
+		x, err := q.RemoveFirst(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != expected {
+			t.Fatalf("expected %d got %d", expected, x)
+		}
+	}
+}
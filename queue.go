@@ -3,5 +3,7 @@ package collections
 type Queue[T any] interface {
 	AddLast(T)
 	RemoveFirst() (T, error)
+	PeekFirst() (T, error)
+	PeekLast() (T, error)
 	Size() uint
 }
@@ -0,0 +1,135 @@
+package collections
+
+import (
+	"errors"
+	"iter"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+type bulkQueue interface {
+	Queue[int]
+	AddAll(...int)
+	AddSeq(iter.Seq[int])
+	RemoveN(int) []int
+	RemoveInto([]int) int
+}
+
+type bulkQueueTestCase struct {
+	name  string
+	queue bulkQueue
+}
+
+func createBulkQueueTests() []bulkQueueTestCase {
+	return []bulkQueueTestCase{
+		{
+			name:  "linked queue",
+			queue: NewLinkedQueue[int](),
+		},
+		{
+			name:  "array queue",
+			queue: NewArrayQueue[int](),
+		},
+		{
+			name:  "array queue with initial capacity",
+			queue: NewArrayQueueWithInitialCapacity[int](16),
+		},
+		{
+			name:  "simple array queue",
+			queue: NewSimpleArrayQueue[int](),
+		},
+	}
+}
+
+func TestQueue_Bulk(t *testing.T) {
+	for _, test := range createBulkQueueTests() {
+		t.Run(test.name, func(t *testing.T) {
+			var expected []int
+			var next = 0
+			for i := 0; i < 1000; i++ {
+				switch rand.Intn(5) {
+				case 0:
+					batch := make([]int, rand.Intn(20))
+					for j := range batch {
+						batch[j] = next
+						next++
+					}
+					test.queue.AddAll(batch...)
+					expected = append(expected, batch...)
+				case 1:
+					batch := orderedIntArray(rand.Intn(20))
+					for j := range batch {
+						batch[j] += next
+					}
+					next += len(batch)
+					test.queue.AddSeq(slices.Values(batch))
+					expected = append(expected, batch...)
+				case 2:
+					test.queue.AddLast(next)
+					expected = append(expected, next)
+					next++
+				case 3:
+					n := rand.Intn(25) - 5
+					removed := test.queue.RemoveN(n)
+					k := max(0, min(n, len(expected)))
+					if !slices.Equal(removed, expected[:k]) {
+						t.Fatalf("expected %v got %v", expected[:k], removed)
+					}
+					expected = expected[k:]
+				case 4:
+					dst := make([]int, rand.Intn(20))
+					n := test.queue.RemoveInto(dst)
+					k := min(len(dst), len(expected))
+					if n != k || !slices.Equal(dst[:n], expected[:k]) {
+						t.Fatalf("expected %v got %v", expected[:k], dst[:n])
+					}
+					expected = expected[k:]
+				}
+				if test.queue.Size() != uint(len(expected)) {
+					t.Fatalf("expected size %d got %d", len(expected), test.queue.Size())
+				}
+			}
+			for _, x := range expected {
+				if y, err := test.queue.RemoveFirst(); err != nil || x != y {
+					t.Fatalf("expected %d got %d, %v", x, y, err)
+				}
+			}
+		})
+	}
+}
+
+func TestArrayQueue_AddAllGrowsOnce(t *testing.T) {
+	var queue = NewArrayQueueWithInitialCapacity[int](4)
+	queue.AddAll(0, 1, 2)
+	if _, err := queue.RemoveFirst(); err != nil {
+		t.Fatal(err)
+	}
+	queue.AddAll(3, 4, 5, 6, 7, 8, 9, 10, 11)
+	if len(queue.array) != 11 {
+		t.Fatalf("expected capacity %d got %d", 11, len(queue.array))
+	}
+	if all := slices.Collect(queue.All()); !slices.Equal(all, orderedIntArray(12)[1:]) {
+		t.Fatalf("expected %v got %v", orderedIntArray(12)[1:], all)
+	}
+}
+
+func TestQueue_EmptyErrors(t *testing.T) {
+	for _, test := range createBulkQueueTests() {
+		t.Run(test.name, func(t *testing.T) {
+			var queueErr *QueueError
+			_, err := test.queue.RemoveFirst()
+			if !errors.Is(err, ErrQueueEmpty) || !errors.As(err, &queueErr) || queueErr.Op != "RemoveFirst" {
+				t.Fatalf("expected queue is empty error from RemoveFirst, got %v", err)
+			}
+			_, err = test.queue.PeekFirst()
+			if !errors.Is(err, ErrQueueEmpty) || !errors.As(err, &queueErr) || queueErr.Op != "PeekFirst" {
+				t.Fatalf("expected queue is empty error from PeekFirst, got %v", err)
+			}
+			_, err = test.queue.PeekLast()
+			if !errors.Is(err, ErrQueueEmpty) || !errors.As(err, &queueErr) || queueErr.Op != "PeekLast" {
+				t.Fatalf("expected queue is empty error from PeekLast, got %v", err)
+			}
+		})
+	}
+}
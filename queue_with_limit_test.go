@@ -2,7 +2,9 @@ package collections
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -41,6 +43,22 @@ func createTests(queueSize uint) ([]testCase, error) {
 		queue: queue,
 	})
 
+	if queue, err = NewSegmentedQueueWithLimit[uint](queueSize); err != nil {
+		return nil, err
+	}
+	result = append(result, testCase{
+		name:  "standard queue on segmented queue",
+		queue: queue,
+	})
+
+	if queue, err = NewLockFreeQueueWithLimit[uint](queueSize); err != nil {
+		return nil, err
+	}
+	result = append(result, testCase{
+		name:  "lock-free queue",
+		queue: queue,
+	})
+
 	queue = NewChannelledQueueWithLimit[uint](queueSize)
 	result = append(result, testCase{
 		name:  "standard queue on channelled queue",
@@ -150,7 +168,7 @@ func TestQueueWithLimit_TryRemoveFirst(t *testing.T) {
 			}
 			for i = 0; i < queueSize; i++ {
 				_, err := test.queue.TryRemoveFirst()
-				if err == nil || err.Error() != "queue is empty" {
+				if !errors.Is(err, ErrQueueEmpty) {
 					t.Fatalf("expected queue is empty error, got %v", err)
 				}
 			}
@@ -175,20 +193,21 @@ func TestQueueWithLimit_AddLast_WithTimeout(t *testing.T) {
 					t.Fatal(err)
 				}
 			}
-			var noTimeout = false
+			var noTimeout atomic.Bool
 			wg := sync.WaitGroup{}
 			for i = 0; i < queueSize; i++ {
+				x := i
 				wg.Go(func() {
 					timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
 					defer cancel()
-					err := test.queue.AddLast(timeout, i)
+					err := test.queue.AddLast(timeout, x)
 					if err == nil {
-						noTimeout = true
+						noTimeout.Store(true)
 					}
 				})
 			}
 			wg.Wait()
-			if noTimeout {
+			if noTimeout.Load() {
 				t.Fatalf("expected timeout while trying to add to a full queue")
 			}
 
@@ -330,3 +349,206 @@ func BenchmarkQueues(b *testing.B) {
 		})
 	}
 }
+
+func createResizableTests(t *testing.T, queueSize uint) []struct {
+	name  string
+	queue ResizableQueueWithLimit[uint]
+} {
+	linked, err := NewLinkedQueueWithLimit[uint](queueSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	array, err := NewArrayQueueWithLimit[uint](queueSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []struct {
+		name  string
+		queue ResizableQueueWithLimit[uint]
+	}{
+		{"standard queue on linked queue", linked},
+		{"standard queue on array queue", array},
+		{"channelled queue", NewChannelledQueueWithLimit[uint](queueSize)},
+	}
+}
+
+func TestQueueWithLimit_SetMaxSize_Grow(t *testing.T) {
+	for _, test := range createResizableTests(t, 2) {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			for i := uint(0); i < 2; i++ {
+				if err := test.queue.AddLast(ctx, i); err != nil {
+					t.Fatal(err)
+				}
+			}
+			errs := make(chan error)
+			go func() {
+				errs <- test.queue.AddLast(ctx, 2)
+			}()
+			time.Sleep(10 * time.Millisecond)
+			if err := test.queue.SetMaxSize(ctx, 4); err != nil {
+				t.Fatal(err)
+			}
+			if test.queue.MaxSize() != 4 {
+				t.Fatalf("expected %d got %d", 4, test.queue.MaxSize())
+			}
+			if err := <-errs; err != nil {
+				t.Fatal(err)
+			}
+			if err := test.queue.TryAddLast(3); err != nil {
+				t.Fatal(err)
+			}
+			if err := test.queue.TryAddLast(4); !errors.Is(err, ErrQueueFull) {
+				t.Fatalf("expected queue is full error, got %v", err)
+			}
+			for i := uint(0); i < 4; i++ {
+				x, err := test.queue.RemoveFirst(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if x != i {
+					t.Fatalf("expected %d got %d", i, x)
+				}
+			}
+		})
+	}
+}
+
+func TestQueueWithLimit_SetMaxSize_Shrink(t *testing.T) {
+	for _, test := range createResizableTests(t, 4) {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			for i := uint(0); i < 4; i++ {
+				if err := test.queue.AddLast(ctx, i); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// consumers drain the queue down to the new size in the background
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				for i := 0; i < 3; i++ {
+					_, _ = test.queue.TryRemoveFirst()
+				}
+			}()
+			if err := test.queue.SetMaxSize(ctx, 1); err != nil {
+				t.Fatal(err)
+			}
+			if test.queue.MaxSize() != 1 {
+				t.Fatalf("expected %d got %d", 1, test.queue.MaxSize())
+			}
+			timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+			defer cancel()
+			for test.queue.Size() > 1 {
+				time.Sleep(time.Millisecond)
+			}
+			if err := test.queue.AddLast(timeout, 4); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected deadline exceeded error, got %v", err)
+			}
+			x, err := test.queue.RemoveFirst(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if x != 3 {
+				t.Fatalf("expected %d got %d", 3, x)
+			}
+			if err := test.queue.TryAddLast(4); err != nil {
+				t.Fatal(err)
+			}
+			if err := test.queue.TryAddLast(5); !errors.Is(err, ErrQueueFull) {
+				t.Fatalf("expected queue is full error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestStandardQueueWithLimit_SetMaxSize_ShrinkBelowSize(t *testing.T) {
+	q, err := NewArrayQueueWithLimit[int](4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		if err := q.TryAddLast(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the standard queue never waits, the missing space is taken as consumers remove elements
+	if err := q.SetMaxSize(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if q.MaxSize() != 2 {
+		t.Fatalf("expected %d got %d", 2, q.MaxSize())
+	}
+	for expected := 3; expected >= 2; expected-- {
+		if _, err := q.TryRemoveFirst(); err != nil {
+			t.Fatal(err)
+		}
+		if err := q.TryAddLast(10); !errors.Is(err, ErrQueueFull) {
+			t.Fatalf("expected queue is full error with size %d, got %v", expected, err)
+		}
+	}
+	if _, err := q.TryRemoveFirst(); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.TryAddLast(10); err != nil {
+		t.Fatal(err)
+	}
+	if q.Size() != 2 {
+		t.Fatalf("expected %d got %d", 2, q.Size())
+	}
+}
+
+func TestChannelledQueueWithLimit_SetMaxSize_Timeout(t *testing.T) {
+	q := NewChannelledQueueWithLimit[uint](4)
+	for i := uint(0); i < 4; i++ {
+		if err := q.TryAddLast(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.SetMaxSize(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded error, got %v", err)
+	}
+	if q.MaxSize() != 4 {
+		t.Fatalf("expected %d got %d", 4, q.MaxSize())
+	}
+	// the queue works as before
+	if _, err := q.TryRemoveFirst(); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.TryAddLast(4); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChannelledQueueWithLimit_SetMaxSize_GrowAcceptsAdds(t *testing.T) {
+	q := NewChannelledQueueWithLimit[uint](4)
+	ctx := context.Background()
+	stop := make(chan struct{})
+	resized := make(chan struct{})
+	go func() {
+		defer close(resized)
+		// the size never exceeds 1, so no resize has to wait for consumers
+		for i := uint(0); !isClosed(stop); i++ {
+			if err := q.SetMaxSize(ctx, 4+i%2); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for i := uint(0); i < 10000; i++ {
+		if err := q.TryAddLast(i); err != nil {
+			t.Fatalf("error when adding %dth element: %v", i, err)
+		}
+		x, err := q.TryRemoveFirst()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != i {
+			t.Fatalf("expected %d got %d", i, x)
+		}
+	}
+	close(stop)
+	<-resized
+}
@@ -0,0 +1,15 @@
+package collections
+
+import (
+	"context"
+)
+
+// ResizableQueueWithLimit QueueWithLimit whose max size can be changed while it is in use.
+type ResizableQueueWithLimit[T any] interface {
+	QueueWithLimit[T]
+
+	// SetMaxSize changes the max number of elements. Growing wakes up blocked producers right away. Shrinking below
+	// the current size keeps the elements, but new ones are not accepted until consumers remove enough of them.
+	// Implementations which have to wait for that return the error of the given context if it is done first.
+	SetMaxSize(context.Context, uint) error
+}
@@ -0,0 +1,220 @@
+package collections
+
+import (
+	"iter"
+)
+
+const defaultSegmentSize = 256
+
+type segment[T any] struct {
+	values []T
+	prev   *segment[T]
+	next   *segment[T]
+}
+
+// SegmentedQueue a deque based on a double-linked list of fixed-size arrays. Unlike ArrayQueue it never copies
+// elements when it grows, and unlike LinkedQueue it allocates once per segment instead of once per element.
+// A drained segment is kept for reuse. This implementation is not threadsafe.
+type SegmentedQueue[T any] struct {
+	head        *segment[T]
+	tail        *segment[T]
+	headIndex   int // index of the first element in head
+	tailIndex   int // index after the last element in tail
+	size        uint
+	segmentSize int
+	spare       *segment[T]
+}
+
+func NewSegmentedQueue[T any]() *SegmentedQueue[T] {
+	return NewSegmentedQueueWithSegmentSize[T](defaultSegmentSize)
+}
+
+// NewSegmentedQueueWithSegmentSize creates a queue storing segmentSize elements per segment. A segmentSize of 0
+// selects the default size.
+func NewSegmentedQueueWithSegmentSize[T any](segmentSize uint) *SegmentedQueue[T] {
+	if segmentSize == 0 {
+		segmentSize = defaultSegmentSize
+	}
+	return &SegmentedQueue[T]{
+		segmentSize: int(segmentSize),
+	}
+}
+
+func (q *SegmentedQueue[T]) AddLast(t T) {
+	if q.tail == nil {
+		q.head = q.newSegment()
+		q.tail = q.head
+		q.headIndex = 0
+		q.tailIndex = 0
+	} else if q.tailIndex == q.segmentSize {
+		s := q.newSegment()
+		s.prev = q.tail
+		q.tail.next = s
+		q.tail = s
+		q.tailIndex = 0
+	}
+	q.tail.values[q.tailIndex] = t
+	q.tailIndex++
+	q.size++
+}
+
+func (q *SegmentedQueue[T]) AddFirst(t T) {
+	if q.head == nil {
+		q.head = q.newSegment()
+		q.tail = q.head
+		q.headIndex = q.segmentSize
+		q.tailIndex = q.segmentSize
+	} else if q.headIndex == 0 {
+		s := q.newSegment()
+		s.next = q.head
+		q.head.prev = s
+		q.head = s
+		q.headIndex = q.segmentSize
+	}
+	q.headIndex--
+	q.head.values[q.headIndex] = t
+	q.size++
+}
+
+func (q *SegmentedQueue[T]) RemoveFirst() (T, error) {
+	var zero T
+	if q.size == 0 {
+		return zero, &QueueError{Op: "RemoveFirst", Err: ErrQueueEmpty}
+	}
+	t := q.head.values[q.headIndex]
+	q.head.values[q.headIndex] = zero
+	q.headIndex++
+	q.size--
+	if q.size == 0 {
+		q.reset()
+	} else if q.headIndex == q.segmentSize {
+		s := q.head
+		q.head = s.next
+		q.head.prev = nil
+		q.headIndex = 0
+		q.recycle(s)
+	}
+	return t, nil
+}
+
+func (q *SegmentedQueue[T]) RemoveLast() (T, error) {
+	var zero T
+	if q.size == 0 {
+		return zero, &QueueError{Op: "RemoveLast", Err: ErrQueueEmpty}
+	}
+	q.tailIndex--
+	t := q.tail.values[q.tailIndex]
+	q.tail.values[q.tailIndex] = zero
+	q.size--
+	if q.size == 0 {
+		q.reset()
+	} else if q.tailIndex == 0 {
+		s := q.tail
+		q.tail = s.prev
+		q.tail.next = nil
+		q.tailIndex = q.segmentSize
+		q.recycle(s)
+	}
+	return t, nil
+}
+
+func (q *SegmentedQueue[T]) PeekFirst() (T, error) {
+	if q.size == 0 {
+		var zero T
+		return zero, &QueueError{Op: "PeekFirst", Err: ErrQueueEmpty}
+	}
+	return q.head.values[q.headIndex], nil
+}
+
+func (q *SegmentedQueue[T]) PeekLast() (T, error) {
+	if q.size == 0 {
+		var zero T
+		return zero, &QueueError{Op: "PeekLast", Err: ErrQueueEmpty}
+	}
+	return q.tail.values[q.tailIndex-1], nil
+}
+
+func (q *SegmentedQueue[T]) Size() uint {
+	return q.size
+}
+
+// All returns an iterator over elements of the queue from the first to the last one without removing them.
+func (q *SegmentedQueue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if q.size == 0 {
+			return
+		}
+		for s := q.head; s != nil; s = s.next {
+			from, to := 0, q.segmentSize
+			if s == q.head {
+				from = q.headIndex
+			}
+			if s == q.tail {
+				to = q.tailIndex
+			}
+			for _, t := range s.values[from:to] {
+				if !yield(t) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over elements of the queue from the last to the first one without removing them.
+func (q *SegmentedQueue[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if q.size == 0 {
+			return
+		}
+		for s := q.tail; s != nil; s = s.prev {
+			from, to := 0, q.segmentSize
+			if s == q.head {
+				from = q.headIndex
+			}
+			if s == q.tail {
+				to = q.tailIndex
+			}
+			for i := to - 1; i >= from; i-- {
+				if !yield(s.values[i]) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Drain returns an iterator that removes elements from the queue as it yields them.
+func (q *SegmentedQueue[T]) Drain() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for q.size > 0 {
+			t, _ := q.RemoveFirst()
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+func (q *SegmentedQueue[T]) newSegment() *segment[T] {
+	if s := q.spare; s != nil {
+		q.spare = nil
+		return s
+	}
+	return &segment[T]{values: make([]T, q.segmentSize)}
+}
+
+// recycle keeps a drained segment, whose values have all been zeroed, for the next growth.
+func (q *SegmentedQueue[T]) recycle(s *segment[T]) {
+	s.prev = nil
+	s.next = nil
+	q.spare = s
+}
+
+func (q *SegmentedQueue[T]) reset() {
+	q.recycle(q.head)
+	q.head = nil
+	q.tail = nil
+	q.headIndex = 0
+	q.tailIndex = 0
+}
@@ -0,0 +1,109 @@
+package collections
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestSegmentedQueue_Iterators(t *testing.T) {
+	var queue = NewSegmentedQueueWithSegmentSize[int](4)
+	for i := 0; i < 3; i++ {
+		queue.AddFirst(-1)
+		if _, err := queue.RemoveFirst(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		queue.AddLast(i)
+	}
+	if all := slices.Collect(queue.All()); !slices.Equal(all, orderedIntArray(10)) {
+		t.Fatalf("expected %v got %v", orderedIntArray(10), all)
+	}
+	if backward := slices.Collect(queue.Backward()); !slices.Equal(backward, reverse(orderedIntArray(10))) {
+		t.Fatalf("expected %v got %v", reverse(orderedIntArray(10)), backward)
+	}
+	if drained := slices.Collect(queue.Drain()); !slices.Equal(drained, orderedIntArray(10)) {
+		t.Fatalf("expected %v got %v", orderedIntArray(10), drained)
+	}
+	if queue.Size() != 0 || queue.head != nil || queue.spare == nil {
+		t.Fatalf("expected an empty queue with a spare segment")
+	}
+}
+
+func TestSegmentedQueue_RecyclesSegments(t *testing.T) {
+	var queue = NewSegmentedQueueWithSegmentSize[*int](4)
+	for i := 0; i < 6; i++ {
+		queue.AddLast(pointerTo(i))
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := queue.RemoveFirst(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	spare := queue.spare
+	if spare == nil {
+		t.Fatalf("expected drained segment to be kept")
+	}
+	for i, x := range spare.values {
+		if x != nil {
+			t.Fatalf("expected slot %d of the drained segment to be zeroed", i)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		queue.AddLast(pointerTo(i))
+	}
+	if queue.tail != spare || queue.spare != nil {
+		t.Fatalf("expected drained segment to be reused")
+	}
+}
+
+type unboundedQueueBenchmark struct {
+	name   string
+	create func() Queue[int]
+}
+
+func createUnboundedQueueBenchmarks() []unboundedQueueBenchmark {
+	return []unboundedQueueBenchmark{
+		{
+			name:   "linked queue",
+			create: func() Queue[int] { return NewLinkedQueue[int]() },
+		},
+		{
+			name:   "array queue",
+			create: func() Queue[int] { return NewArrayQueue[int]() },
+		},
+		{
+			name:   "simple array queue",
+			create: func() Queue[int] { return NewSimpleArrayQueue[int]() },
+		},
+		{
+			name:   "segmented queue",
+			create: func() Queue[int] { return NewSegmentedQueue[int]() },
+		},
+	}
+}
+
+func BenchmarkUnboundedQueues(b *testing.B) {
+	for _, burst := range []int{1, 1000, 100_000} {
+		for _, test := range createUnboundedQueueBenchmarks() {
+			b.Run(fmt.Sprintf("%s, burst %d", test.name, burst), func(b *testing.B) {
+				queue := test.create()
+				for i := 0; i < b.N; i++ {
+					for j := 0; j < burst; j++ {
+						queue.AddLast(j)
+					}
+					for j := 0; j < burst; j++ {
+						x, err := queue.RemoveFirst()
+						if err != nil {
+							b.Fatal(err)
+						}
+						if x != j {
+							b.Fatalf("expected %d, got %d", j, x)
+						}
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package collections
+
+// ShrinkPolicy decides whether the backing array of a collection should be reallocated after an element has been
+// removed. It is given the current number of elements and the capacity of the backing array and returns the capacity
+// the array should have. Returning a value not smaller than capacity keeps the array untouched.
+type ShrinkPolicy func(size, capacity uint) uint
+
+// NeverShrink keeps a backing array at the largest capacity it has ever grown to. This is the default policy.
+func NeverShrink(_, capacity uint) uint {
+	return capacity
+}
+
+// ShrinkBelow returns a policy that halves the capacity whenever less than the given fraction of it is occupied,
+// without going below minCapacity.
+func ShrinkBelow(fraction float64, minCapacity uint) ShrinkPolicy {
+	return func(size, capacity uint) uint {
+		if capacity <= minCapacity || float64(size) >= fraction*float64(capacity) {
+			return capacity
+		}
+		return max(capacity/2, size, minCapacity)
+	}
+}
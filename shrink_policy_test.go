@@ -0,0 +1,153 @@
+package collections
+
+import (
+	"testing"
+)
+
+func TestShrinkBelow(t *testing.T) {
+	policy := ShrinkBelow(0.25, 8)
+	tests := []struct {
+		size, capacity, expected uint
+	}{
+		{size: 0, capacity: 8, expected: 8},
+		{size: 0, capacity: 4, expected: 4},
+		{size: 4, capacity: 16, expected: 16},
+		{size: 3, capacity: 16, expected: 8},
+		{size: 10, capacity: 64, expected: 32},
+		{size: 0, capacity: 1024, expected: 512},
+	}
+	for _, test := range tests {
+		if actual := policy(test.size, test.capacity); actual != test.expected {
+			t.Errorf("policy(%d, %d): expected %d got %d", test.size, test.capacity, test.expected, actual)
+		}
+	}
+}
+
+func TestArrayQueue_Shrink(t *testing.T) {
+	var queue = NewArrayQueue[int]()
+	queue.SetShrinkPolicy(ShrinkBelow(0.25, 4))
+	var n = 1000
+	for i := 0; i < n; i++ {
+		queue.AddLast(i)
+	}
+	for i := 0; i < n-2; i++ {
+		x, err := queue.RemoveFirst()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != i {
+			t.Fatalf("expected %d got %d", i, x)
+		}
+	}
+	if len(queue.array) > 8 {
+		t.Fatalf("expected backing array to shrink, capacity %d", len(queue.array))
+	}
+	for i := n - 2; i < n; i++ {
+		if x, err := queue.RemoveFirst(); err != nil || x != i {
+			t.Fatalf("expected %d got %d, %v", i, x, err)
+		}
+	}
+	queue.AddLast(1)
+	queue.TrimToSize()
+	if len(queue.array) != 1 {
+		t.Fatalf("expected capacity %d got %d", 1, len(queue.array))
+	}
+	queue.AddLast(2)
+	if x, err := queue.RemoveFirst(); err != nil || x != 1 {
+		t.Fatalf("expected %d got %d, %v", 1, x, err)
+	}
+}
+
+func TestArrayQueue_VacatedSlotsAreZeroed(t *testing.T) {
+	var queue = NewArrayQueue[*int]()
+	for i := 0; i < 4; i++ {
+		queue.AddLast(pointerTo(i))
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := queue.RemoveFirst(); err != nil {
+			t.Fatal(err)
+		}
+		queue.AddLast(pointerTo(i))
+	}
+	queue.AddLast(pointerTo(5))
+	for i := 0; i < len(queue.array); i++ {
+		if uint((i-queue.head+len(queue.array))%len(queue.array)) >= queue.size && queue.array[i] != nil {
+			t.Fatalf("expected slot %d outside the queue to be zeroed", i)
+		}
+	}
+}
+
+func TestSimpleArrayQueue_Shrink(t *testing.T) {
+	var queue = NewSimpleArrayQueue[int]()
+	queue.SetShrinkPolicy(ShrinkBelow(0.25, 4))
+	var n = 1000
+	for i := 0; i < n; i++ {
+		queue.AddLast(i)
+	}
+	for i := 0; i < n-2; i++ {
+		if x, err := queue.RemoveFirst(); err != nil || x != i {
+			t.Fatalf("expected %d got %d, %v", i, x, err)
+		}
+	}
+	if queue.capacity > 8 {
+		t.Fatalf("expected backing array to shrink, capacity %d", queue.capacity)
+	}
+	queue.TrimToSize()
+	if cap(queue.queue) != 2 {
+		t.Fatalf("expected capacity %d got %d", 2, cap(queue.queue))
+	}
+	for i := n - 2; i < n; i++ {
+		if x, err := queue.RemoveFirst(); err != nil || x != i {
+			t.Fatalf("expected %d got %d, %v", i, x, err)
+		}
+	}
+}
+
+func TestHeap_Shrink(t *testing.T) {
+	var heap = NewHeap[int](0)
+	heap.SetShrinkPolicy(ShrinkBelow(0.25, 4))
+	var n = 1000
+	for _, x := range randomIntArray(n) {
+		heap.Add(x)
+	}
+	for i := 0; i < n-2; i++ {
+		if x, err := heap.Remove(); err != nil || x != i {
+			t.Fatalf("expected %d got %d, %v", i, x, err)
+		}
+	}
+	if cap(heap.array) > 8 {
+		t.Fatalf("expected backing array to shrink, capacity %d", cap(heap.array))
+	}
+	heap.TrimToSize()
+	if cap(heap.array) != 2 {
+		t.Fatalf("expected capacity %d got %d", 2, cap(heap.array))
+	}
+	for i := n - 2; i < n; i++ {
+		if x, err := heap.Remove(); err != nil || x != i {
+			t.Fatalf("expected %d got %d, %v", i, x, err)
+		}
+	}
+}
+
+func TestHeap_VacatedSlotsAreZeroed(t *testing.T) {
+	var heap = NewHeapWithCompare(0, func(x, y *int) int {
+		return *x - *y
+	})
+	for i := 0; i < 10; i++ {
+		heap.Add(pointerTo(i))
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := heap.Remove(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := heap.size; i < len(heap.array); i++ {
+		if heap.array[i] != nil {
+			t.Fatalf("expected slot %d outside the heap to be zeroed", i)
+		}
+	}
+}
+
+func pointerTo(i int) *int {
+	return &i
+}
@@ -0,0 +1,76 @@
+package collections
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// spinCount number of attempts lock-free queues make before they park the goroutine.
+const spinCount = 64
+
+// cacheLinePad separates fields written by different goroutines to avoid false sharing.
+type cacheLinePad [64]byte
+
+// signal wakes up goroutines waiting for a state change, e.g. for a queue to become non-empty. A waiter calls wait,
+// re-checks the state, blocks on the returned channel if it still needs to and calls done once it stops waiting.
+type signal struct {
+	waiters atomic.Int64
+	lock    sync.Mutex
+	c       chan struct{}
+}
+
+func newSignal() *signal {
+	return &signal{
+		c: make(chan struct{}),
+	}
+}
+
+// wait registers the caller as a waiter and returns a channel closed by the next notify.
+func (s *signal) wait() <-chan struct{} {
+	s.waiters.Add(1)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.c
+}
+
+func (s *signal) done() {
+	s.waiters.Add(-1)
+}
+
+// notify wakes up all registered waiters. It does not take the lock if there are none.
+func (s *signal) notify() {
+	if s.waiters.Load() == 0 {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	close(s.c)
+	s.c = make(chan struct{})
+}
+
+// await calls try until it succeeds. It spins for the given number of attempts and then parks until s is notified,
+// giving up when ctx is done.
+func await(ctx context.Context, s *signal, spins int, try func() bool) error {
+	for i := 0; i < spins; i++ {
+		if try() {
+			return nil
+		}
+		runtime.Gosched()
+	}
+	for {
+		c := s.wait()
+		if try() {
+			s.done()
+			return nil
+		}
+		select {
+		case <-c:
+			s.done()
+		case <-ctx.Done():
+			s.done()
+			return ctx.Err()
+		}
+	}
+}
@@ -1,9 +1,15 @@
 package collections
 
-import "errors"
+import (
+	"iter"
+	"slices"
+)
 
 type SimpleArrayQueue[T any] struct {
 	queue []T
+	// capacity of the whole backing array, including the part before queue that has been already removed
+	capacity     uint
+	shrinkPolicy ShrinkPolicy
 }
 
 func NewSimpleArrayQueue[T any]() *SimpleArrayQueue[T] {
@@ -14,24 +20,156 @@ func NewSimpleArrayQueue[T any]() *SimpleArrayQueue[T] {
 
 func NewSimpleArrayQueueWithInitialCapacity[T any](capacity uint) *SimpleArrayQueue[T] {
 	return &SimpleArrayQueue[T]{
-		queue: make([]T, 0, capacity),
+		queue:    make([]T, 0, capacity),
+		capacity: capacity,
 	}
 }
 
 func (q *SimpleArrayQueue[T]) AddLast(t T) {
-	q.queue = append(q.queue, t)
+	if len(q.queue) == cap(q.queue) {
+		q.queue = append(q.queue, t)
+		q.capacity = uint(cap(q.queue))
+	} else {
+		q.queue = append(q.queue, t)
+	}
+}
+
+// AddAll adds elements to the end of the queue, growing the backing array at most once.
+func (q *SimpleArrayQueue[T]) AddAll(ts ...T) {
+	if len(q.queue)+len(ts) > cap(q.queue) {
+		q.queue = append(q.queue, ts...)
+		q.capacity = uint(cap(q.queue))
+	} else {
+		q.queue = append(q.queue, ts...)
+	}
+}
+
+// AddSeq adds all elements of the sequence to the end of the queue.
+func (q *SimpleArrayQueue[T]) AddSeq(seq iter.Seq[T]) {
+	for t := range seq {
+		q.AddLast(t)
+	}
 }
 
 func (q *SimpleArrayQueue[T]) RemoveFirst() (T, error) {
 	var zero T
 	if len(q.queue) == 0 {
-		return zero, errors.New("queue is empty")
+		return zero, &QueueError{Op: "RemoveFirst", Err: ErrQueueEmpty}
 	}
 	var result T = q.queue[0]
+	q.queue[0] = zero
 	q.queue = q.queue[1:]
+	q.shrinkIfNeeded()
 	return result, nil
 }
 
+// RemoveN removes up to n first elements from the queue and returns them.
+func (q *SimpleArrayQueue[T]) RemoveN(n int) []T {
+	result := make([]T, max(0, min(n, len(q.queue))))
+	q.RemoveInto(result)
+	return result
+}
+
+// RemoveInto removes first elements from the queue into dst until either dst is full or the queue is empty.
+// Returns the number of removed elements.
+func (q *SimpleArrayQueue[T]) RemoveInto(dst []T) int {
+	n := copy(dst, q.queue)
+	if n == 0 {
+		return 0
+	}
+	clear(q.queue[:n])
+	q.queue = q.queue[n:]
+	q.shrinkIfNeeded()
+	return n
+}
+
+func (q *SimpleArrayQueue[T]) PeekFirst() (T, error) {
+	var zero T
+	if len(q.queue) == 0 {
+		return zero, &QueueError{Op: "PeekFirst", Err: ErrQueueEmpty}
+	}
+	return q.queue[0], nil
+}
+
+func (q *SimpleArrayQueue[T]) PeekLast() (T, error) {
+	var zero T
+	if len(q.queue) == 0 {
+		return zero, &QueueError{Op: "PeekLast", Err: ErrQueueEmpty}
+	}
+	return q.queue[len(q.queue)-1], nil
+}
+
+// Get returns i-th element of the queue counting from the first one, in constant time.
+func (q *SimpleArrayQueue[T]) Get(i uint) (T, error) {
+	if i >= uint(len(q.queue)) {
+		var zero T
+		return zero, &IndexOutOfRangeError{Index: i, Size: uint(len(q.queue))}
+	}
+	return q.queue[i], nil
+}
+
 func (q *SimpleArrayQueue[T]) Size() uint {
 	return uint(len(q.queue))
 }
+
+// SetShrinkPolicy sets the policy deciding when the backing array is reallocated to a smaller one after removals.
+// A nil policy never shrinks.
+func (q *SimpleArrayQueue[T]) SetShrinkPolicy(policy ShrinkPolicy) {
+	q.shrinkPolicy = policy
+}
+
+// TrimToSize reallocates the backing array so that its capacity equals the number of elements in the queue.
+func (q *SimpleArrayQueue[T]) TrimToSize() {
+	q.resize(uint(len(q.queue)))
+}
+
+func (q *SimpleArrayQueue[T]) shrinkIfNeeded() {
+	if q.shrinkPolicy == nil {
+		return
+	}
+	size := uint(len(q.queue))
+	if newCapacity := q.shrinkPolicy(size, q.capacity); newCapacity < q.capacity {
+		q.resize(max(newCapacity, size))
+	}
+}
+
+func (q *SimpleArrayQueue[T]) resize(capacity uint) {
+	queue := make([]T, len(q.queue), capacity)
+	copy(queue, q.queue)
+	q.queue = queue
+	q.capacity = capacity
+}
+
+// All returns an iterator over elements of the queue from the first to the last one without removing them.
+func (q *SimpleArrayQueue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, t := range q.queue {
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over elements of the queue from the last to the first one without removing them.
+func (q *SimpleArrayQueue[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, t := range slices.Backward(q.queue) {
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// Drain returns an iterator that removes elements from the queue as it yields them.
+func (q *SimpleArrayQueue[T]) Drain() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for len(q.queue) > 0 {
+			t, _ := q.RemoveFirst()
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
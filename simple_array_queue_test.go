@@ -0,0 +1,52 @@
+package collections
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSimpleArrayQueue_Iterators(t *testing.T) {
+	var queue = NewSimpleArrayQueue[int]()
+	for i := 0; i < 10; i++ {
+		queue.AddLast(i)
+	}
+	if all := slices.Collect(queue.All()); !slices.Equal(all, orderedIntArray(10)) {
+		t.Fatalf("expected %v got %v", orderedIntArray(10), all)
+	}
+	if backward := slices.Collect(queue.Backward()); !slices.Equal(backward, reverse(orderedIntArray(10))) {
+		t.Fatalf("expected %v got %v", reverse(orderedIntArray(10)), backward)
+	}
+	if drained := slices.Collect(queue.Drain()); !slices.Equal(drained, orderedIntArray(10)) {
+		t.Fatalf("expected %v got %v", orderedIntArray(10), drained)
+	}
+	if queue.Size() != 0 {
+		t.Fatalf("expected %d got %d", 0, queue.Size())
+	}
+}
+
+func TestSimpleArrayQueue_PeekAndGet(t *testing.T) {
+	var queue = NewSimpleArrayQueue[int]()
+	if _, err := queue.PeekFirst(); err == nil {
+		t.Fatalf("expected error when peeking into an empty queue")
+	}
+	for i := 0; i < 10; i++ {
+		queue.AddLast(i)
+	}
+	if _, err := queue.RemoveFirst(); err != nil {
+		t.Fatal(err)
+	}
+	if first, err := queue.PeekFirst(); err != nil || first != 1 {
+		t.Fatalf("expected %d got %d, %v", 1, first, err)
+	}
+	if last, err := queue.PeekLast(); err != nil || last != 9 {
+		t.Fatalf("expected %d got %d, %v", 9, last, err)
+	}
+	if x, err := queue.Get(3); err != nil || x != 4 {
+		t.Fatalf("expected %d got %d, %v", 4, x, err)
+	}
+	var indexErr *IndexOutOfRangeError
+	if _, err := queue.Get(9); !errors.As(err, &indexErr) {
+		t.Fatalf("expected index out of range error, got %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+package collections
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+type spill struct {
+	path  string
+	count uint
+}
+
+// SpillingQueue a queue which keeps at most memoryLimit elements in memory and spills the rest to temporary files.
+// The first and the last elements are kept in two ArrayQueues, each holding up to half of the limit. Once the tail
+// fills up while there are older elements waiting, it is written to a new file, and the files are read back in order
+// when the head runs out of elements. This implementation is not threadsafe.
+//
+// AddLast cannot return an error, so a failed write keeps the elements in memory and is reported by Err. The write
+// is retried every time the tail grows by another chunk. Temporary files are removed by Close.
+type SpillingQueue[T any] struct {
+	dir       string
+	codec     Codec[T]
+	chunkSize uint
+	head      *ArrayQueue[T]
+	spills    *ArrayQueue[spill]
+	spilled   uint
+	tail      *ArrayQueue[T]
+	last      T
+	err       error
+}
+
+// NewSpillingQueue creates a queue spilling to temporary files in dir, or in the default directory for temporary
+// files if dir is empty.
+func NewSpillingQueue[T any](dir string, memoryLimit uint, codec Codec[T]) *SpillingQueue[T] {
+	chunkSize := max(memoryLimit/2, 1)
+	return &SpillingQueue[T]{
+		dir:       dir,
+		codec:     codec,
+		chunkSize: chunkSize,
+		head:      NewArrayQueueWithInitialCapacity[T](chunkSize),
+		spills:    NewArrayQueue[spill](),
+		tail:      NewArrayQueueWithInitialCapacity[T](chunkSize),
+	}
+}
+
+// SpillingQueueWithLimit a QueueWithLimit built on a SpillingQueue it owns, the limit applies to all elements
+// including spilled ones.
+type SpillingQueueWithLimit[T any] struct {
+	*StandardQueueWithLimit[T]
+	spilling *SpillingQueue[T]
+}
+
+// NewSpillingQueueWithLimit creates a queue storing at most maxSize elements, at most memoryLimit of them in memory
+// and the rest in temporary files in dir, or in the default directory for temporary files if dir is empty.
+func NewSpillingQueueWithLimit[T any](dir string, memoryLimit, maxSize uint, codec Codec[T]) (*SpillingQueueWithLimit[T], error) {
+	spilling := NewSpillingQueue[T](dir, memoryLimit, codec)
+	q, err := newQueueWithLimit[T](maxSize, spilling)
+	if err != nil {
+		return nil, err
+	}
+	return &SpillingQueueWithLimit[T]{StandardQueueWithLimit: q, spilling: spilling}, nil
+}
+
+// Err returns the first error of writing or reading a temporary file, even if a later attempt has succeeded.
+func (q *SpillingQueueWithLimit[T]) Err() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.spilling.Err()
+}
+
+// Spilled returns the number of elements currently stored in files.
+func (q *SpillingQueueWithLimit[T]) Spilled() uint {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.spilling.Spilled()
+}
+
+// Discard closes the queue like CloseNow and removes all temporary files, dropping the elements stored in them.
+// Close keeps the files, so that consumers can still remove the remaining elements, and the files are removed as
+// they are read.
+func (q *SpillingQueueWithLimit[T]) Discard() error {
+	q.CloseNow()
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.spilling.Close()
+}
+
+func (q *SpillingQueue[T]) AddLast(t T) {
+	q.last = t
+	if q.spills.Size() == 0 && q.tail.Size() == 0 && q.head.Size() < q.chunkSize {
+		q.head.AddLast(t)
+		return
+	}
+	q.tail.AddLast(t)
+	if q.tail.Size()%q.chunkSize == 0 {
+		q.spillTail()
+	}
+}
+
+func (q *SpillingQueue[T]) RemoveFirst() (T, error) {
+	if err := q.fillHead(); err != nil {
+		var zero T
+		return zero, err
+	}
+	if q.head.Size() == 0 {
+		var zero T
+		return zero, &QueueError{Op: "RemoveFirst", Err: ErrQueueEmpty}
+	}
+	t, err := q.head.RemoveFirst()
+	q.clearLastIfEmpty()
+	return t, err
+}
+
+func (q *SpillingQueue[T]) PeekFirst() (T, error) {
+	if err := q.fillHead(); err != nil {
+		var zero T
+		return zero, err
+	}
+	if q.head.Size() == 0 {
+		var zero T
+		return zero, &QueueError{Op: "PeekFirst", Err: ErrQueueEmpty}
+	}
+	return q.head.PeekFirst()
+}
+
+func (q *SpillingQueue[T]) PeekLast() (T, error) {
+	if q.Size() == 0 {
+		var zero T
+		return zero, &QueueError{Op: "PeekLast", Err: ErrQueueEmpty}
+	}
+	return q.last, nil
+}
+
+func (q *SpillingQueue[T]) Size() uint {
+	return q.head.Size() + q.spilled + q.tail.Size()
+}
+
+// Spilled returns the number of elements currently stored in files.
+func (q *SpillingQueue[T]) Spilled() uint {
+	return q.spilled
+}
+
+// Err returns the first error of writing or reading a temporary file, even if a later attempt has succeeded.
+func (q *SpillingQueue[T]) Err() error {
+	return q.err
+}
+
+// Close removes all temporary files, dropping the elements stored in them.
+func (q *SpillingQueue[T]) Close() error {
+	var err error
+	for s := range q.spills.Drain() {
+		err = errors.Join(err, os.Remove(s.path))
+	}
+	q.spilled = 0
+	q.clearLastIfEmpty()
+	return err
+}
+
+// clearLastIfEmpty drops the reference to the last element once it has been removed.
+func (q *SpillingQueue[T]) clearLastIfEmpty() {
+	if q.Size() == 0 {
+		var zero T
+		q.last = zero
+	}
+}
+
+// fail records err unless an earlier error has been recorded already.
+func (q *SpillingQueue[T]) fail(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// fillHead moves the next chunk of elements to the empty head, either from the oldest file or from the tail.
+func (q *SpillingQueue[T]) fillHead() error {
+	if q.head.Size() > 0 {
+		return nil
+	}
+	if q.spills.Size() == 0 {
+		q.head, q.tail = q.tail, q.head
+		return nil
+	}
+	s, _ := q.spills.PeekFirst()
+	if err := q.readSpill(s); err != nil {
+		// the file is kept, so that the read may be retried
+		q.head = NewArrayQueueWithInitialCapacity[T](q.chunkSize)
+		q.fail(err)
+		return err
+	}
+	_, _ = q.spills.RemoveFirst()
+	q.spilled -= s.count
+	// the elements have been read, failing to remove the file only leaves garbage behind
+	_ = os.Remove(s.path)
+	return nil
+}
+
+func (q *SpillingQueue[T]) spillTail() {
+	file, err := os.CreateTemp(q.dir, "spilling-queue-*")
+	if err != nil {
+		q.fail(err)
+		return
+	}
+	writer := bufio.NewWriter(file)
+	var buffer []byte
+	for t := range q.tail.All() {
+		payload, err := q.codec.Encode(t)
+		if err == nil {
+			buffer = binary.AppendUvarint(buffer[:0], uint64(len(payload)))
+			buffer = append(buffer, payload...)
+			_, err = writer.Write(buffer)
+		}
+		if err != nil {
+			q.fail(err)
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+			return
+		}
+	}
+	if err := errors.Join(writer.Flush(), file.Close()); err != nil {
+		q.fail(err)
+		_ = os.Remove(file.Name())
+		return
+	}
+	q.spills.AddLast(spill{path: file.Name(), count: q.tail.Size()})
+	q.spilled += q.tail.Size()
+	q.tail = NewArrayQueueWithInitialCapacity[T](q.chunkSize)
+}
+
+func (q *SpillingQueue[T]) readSpill(s spill) error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	for i := uint(0); i < s.count; i++ {
+		length, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return err
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return err
+		}
+		t, err := q.codec.Decode(payload)
+		if err != nil {
+			return err
+		}
+		q.head.AddLast(t)
+	}
+	return nil
+}
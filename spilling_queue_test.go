@@ -0,0 +1,232 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func spillFiles(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "spilling-queue-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestSpillingQueue_Randomized(t *testing.T) {
+	dir := t.TempDir()
+	q := NewSpillingQueue[int](dir, 10, GobCodec[int]{})
+	defer q.Close()
+	r := rand.New(rand.NewSource(1))
+	next, expected := 0, 0
+	for i := 0; i < 10000; i++ {
+		if r.Intn(3) > 0 {
+			q.AddLast(next)
+			next++
+		} else if q.Size() > 0 {
+			x, err := q.RemoveFirst()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if x != expected {
+				t.Fatalf("expected %d got %d", expected, x)
+			}
+			expected++
+		}
+		if q.Size() != uint(next-expected) {
+			t.Fatalf("expected size %d got %d", next-expected, q.Size())
+		}
+		if inMemory := q.head.Size() + q.tail.Size(); inMemory > 10 {
+			t.Fatalf("expected at most %d elements in memory got %d", 10, inMemory)
+		}
+		if i%100 == 0 {
+			if files := spillFiles(t, dir); uint(len(files)) != q.spills.Size() {
+				t.Fatalf("expected %d files got %d", q.spills.Size(), len(files))
+			}
+		}
+	}
+	if q.Spilled() == 0 {
+		t.Fatalf("expected some elements to be spilled")
+	}
+	if x, _ := q.PeekLast(); x != next-1 {
+		t.Fatalf("expected %d got %d", next-1, x)
+	}
+	for q.Size() > 0 {
+		x, err := q.RemoveFirst()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != expected {
+			t.Fatalf("expected %d got %d", expected, x)
+		}
+		expected++
+	}
+	if files := spillFiles(t, dir); len(files) != 0 {
+		t.Fatalf("expected %d files got %d", 0, len(files))
+	}
+	if err := q.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSpillingQueue_Close(t *testing.T) {
+	dir := t.TempDir()
+	q := NewSpillingQueue[int](dir, 4, GobCodec[int]{})
+	for i := 0; i < 20; i++ {
+		q.AddLast(i)
+	}
+	if len(spillFiles(t, dir)) == 0 {
+		t.Fatalf("expected some spill files")
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if files := spillFiles(t, dir); len(files) != 0 {
+		t.Fatalf("expected %d files got %d", 0, len(files))
+	}
+}
+
+func TestSpillingQueue_WriteError(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+	q := NewSpillingQueue[int](dir, 4, GobCodec[int]{})
+	defer q.Close()
+	for i := 0; i < 20; i++ {
+		q.AddLast(i)
+	}
+	if q.Err() == nil {
+		t.Fatalf("expected error spilling to a missing directory")
+	}
+	if q.Spilled() != 0 {
+		t.Fatalf("expected %d got %d", 0, q.Spilled())
+	}
+	// spilling is retried once the tail grows by another chunk
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 20; i < 22; i++ {
+		q.AddLast(i)
+	}
+	if inMemory := q.head.Size() + q.tail.Size(); inMemory > 4 {
+		t.Fatalf("expected at most %d elements in memory got %d", 4, inMemory)
+	}
+	if q.Err() == nil {
+		t.Fatalf("expected the first error to be kept")
+	}
+	for i := 0; i < 22; i++ {
+		x, err := q.RemoveFirst()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != i {
+			t.Fatalf("expected %d got %d", i, x)
+		}
+	}
+}
+
+func TestSpillingQueue_ReadError(t *testing.T) {
+	dir := t.TempDir()
+	q := NewSpillingQueue[int](dir, 4, GobCodec[int]{})
+	for i := 0; i < 20; i++ {
+		q.AddLast(i)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := q.RemoveFirst(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldest, _ := q.spills.PeekFirst()
+	if err := os.Truncate(oldest.path, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.RemoveFirst(); err == nil {
+		t.Fatalf("expected error reading a truncated spill file")
+	}
+	if q.Err() == nil {
+		t.Fatalf("expected error to be reported by Err")
+	}
+	if q.Size() != 18 {
+		t.Fatalf("expected %d got %d", 18, q.Size())
+	}
+}
+
+func TestSpillingQueueWithLimit_ReadError(t *testing.T) {
+	dir := t.TempDir()
+	q, err := NewSpillingQueueWithLimit[int](dir, 4, 20, GobCodec[int]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Discard()
+	ctx := context.Background()
+	for i := 0; i < 20; i++ {
+		if err := q.AddLast(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := q.RemoveFirst(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldest, _ := q.spilling.spills.PeekFirst()
+	if err := os.Truncate(oldest.path, 1); err != nil {
+		t.Fatal(err)
+	}
+	// the element which could not be read still counts, so the next attempt fails the same way
+	for i := 0; i < 2; i++ {
+		if _, err := q.TryRemoveFirst(); err == nil || errors.Is(err, ErrQueueEmpty) {
+			t.Fatalf("expected read error, got %v", err)
+		}
+	}
+	if q.Size() != 18 {
+		t.Fatalf("expected %d got %d", 18, q.Size())
+	}
+	if q.Err() == nil {
+		t.Fatalf("expected the read error to be reported")
+	}
+}
+
+func TestSpillingQueueWithLimit_Discard(t *testing.T) {
+	dir := t.TempDir()
+	q, err := NewSpillingQueueWithLimit[int](dir, 4, 20, GobCodec[int]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for i := 0; i < 20; i++ {
+		if err := q.AddLast(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if q.Spilled() == 0 {
+		t.Fatalf("expected spilled elements")
+	}
+	if err := q.Discard(); err != nil {
+		t.Fatal(err)
+	}
+	if files := spillFiles(t, dir); len(files) != 0 {
+		t.Fatalf("expected no files got %v", files)
+	}
+	if err := q.AddLast(ctx, 20); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected closed error, got %v", err)
+	}
+}
+
+func TestSpillingQueue_ClearsLast(t *testing.T) {
+	q := NewSpillingQueue[*int](t.TempDir(), 4, GobCodec[*int]{})
+	defer q.Close()
+	for i := 0; i < 10; i++ {
+		q.AddLast(&i)
+	}
+	for q.Size() > 0 {
+		if _, err := q.RemoveFirst(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if q.last != nil {
+		t.Fatalf("expected no reference to the removed last element")
+	}
+}
@@ -0,0 +1,164 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"math/bits"
+	"sync/atomic"
+)
+
+// SPSCQueue an implementation of QueueWithLimit for exactly one producer goroutine and one consumer goroutine.
+// Elements are stored in a power-of-two ring; each side owns one index and keeps a cached copy of the other one,
+// so the non-blocking operations are wait-free and touch the shared cache lines only when the cached view runs out.
+// Using it from more than one producer or more than one consumer at a time corrupts the queue.
+type SPSCQueue[T any] struct {
+	_ cacheLinePad
+	// head position of the next element to remove, written by the consumer
+	head atomic.Uint64
+	// cachedTail consumer's last view of tail
+	cachedTail uint64
+	_          cacheLinePad
+	// tail position of the next element to add, written by the producer
+	tail atomic.Uint64
+	// cachedHead producer's last view of head
+	cachedHead uint64
+	_          cacheLinePad
+	buffer     []T
+	mask       uint64
+	maxSize    uint64
+	notEmpty   *signal
+	notFull    *signal
+}
+
+func NewSPSCQueue[T any](maxSize uint) (*SPSCQueue[T], error) {
+	if maxSize == 0 {
+		return nil, errors.New("maxSize must be positive")
+	}
+	capacity := uint64(1) << bits.Len64(uint64(maxSize)-1)
+	return &SPSCQueue[T]{
+		buffer:   make([]T, capacity),
+		mask:     capacity - 1,
+		maxSize:  uint64(maxSize),
+		notEmpty: newSignal(),
+		notFull:  newSignal(),
+	}, nil
+}
+
+func (q *SPSCQueue[T]) AddLast(ctx context.Context, t T) error {
+	return await(ctx, q.notFull, spinCount, func() bool {
+		return q.tryAddLast(t)
+	})
+}
+
+func (q *SPSCQueue[T]) TryAddLast(t T) error {
+	if !q.tryAddLast(t) {
+		return &QueueError{Op: "TryAddLast", Capacity: q.MaxSize(), Err: ErrQueueFull}
+	}
+	return nil
+}
+
+// TryAddAll adds as many elements as there is space for and publishes them to the consumer at once.
+// Returns the number of added elements.
+func (q *SPSCQueue[T]) TryAddAll(values []T) int {
+	tail := q.tail.Load()
+	free := q.maxSize - (tail - q.cachedHead)
+	if free < uint64(len(values)) {
+		q.cachedHead = q.head.Load()
+		free = q.maxSize - (tail - q.cachedHead)
+	}
+	n := min(uint64(len(values)), free)
+	if n == 0 {
+		return 0
+	}
+	for i := uint64(0); i < n; i++ {
+		q.buffer[(tail+i)&q.mask] = values[i]
+	}
+	q.tail.Store(tail + n)
+	q.notEmpty.notify()
+	return int(n)
+}
+
+func (q *SPSCQueue[T]) RemoveFirst(ctx context.Context) (t T, err error) {
+	err = await(ctx, q.notEmpty, spinCount, func() (ok bool) {
+		t, ok = q.tryRemoveFirst()
+		return ok
+	})
+	return t, err
+}
+
+func (q *SPSCQueue[T]) TryRemoveFirst() (T, error) {
+	t, ok := q.tryRemoveFirst()
+	if !ok {
+		return t, &QueueError{Op: "TryRemoveFirst", Capacity: q.MaxSize(), Err: ErrQueueEmpty}
+	}
+	return t, nil
+}
+
+// TryRemoveInto removes as many elements as are available into dst, releasing their slots to the producer at once.
+// Returns the number of removed elements.
+func (q *SPSCQueue[T]) TryRemoveInto(dst []T) int {
+	head := q.head.Load()
+	available := q.cachedTail - head
+	if available < uint64(len(dst)) {
+		q.cachedTail = q.tail.Load()
+		available = q.cachedTail - head
+	}
+	n := min(uint64(len(dst)), available)
+	if n == 0 {
+		return 0
+	}
+	var zero T
+	for i := uint64(0); i < n; i++ {
+		index := (head + i) & q.mask
+		dst[i] = q.buffer[index]
+		q.buffer[index] = zero
+	}
+	q.head.Store(head + n)
+	q.notFull.notify()
+	return int(n)
+}
+
+func (q *SPSCQueue[T]) MaxSize() uint {
+	return uint(q.maxSize)
+}
+
+// Size returns an approximate number of elements, as it may change while it is being computed.
+func (q *SPSCQueue[T]) Size() uint {
+	head := q.head.Load()
+	tail := q.tail.Load()
+	if tail <= head {
+		return 0
+	}
+	return uint(min(tail-head, q.maxSize))
+}
+
+func (q *SPSCQueue[T]) tryAddLast(t T) bool {
+	tail := q.tail.Load()
+	if tail-q.cachedHead == q.maxSize {
+		q.cachedHead = q.head.Load()
+		if tail-q.cachedHead == q.maxSize {
+			return false
+		}
+	}
+	q.buffer[tail&q.mask] = t
+	q.tail.Store(tail + 1)
+	q.notEmpty.notify()
+	return true
+}
+
+func (q *SPSCQueue[T]) tryRemoveFirst() (t T, ok bool) {
+	head := q.head.Load()
+	if head == q.cachedTail {
+		q.cachedTail = q.tail.Load()
+		if head == q.cachedTail {
+			return t, false
+		}
+	}
+	var zero T
+	index := head & q.mask
+	t = q.buffer[index]
+	q.buffer[index] = zero
+	q.head.Store(head + 1)
+	q.notFull.notify()
+	return t, true
+}
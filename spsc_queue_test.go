@@ -0,0 +1,147 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSPSCQueue_HappyPath(t *testing.T) {
+	var queueSize uint = 10
+	queue, err := NewSPSCQueue[uint](queueSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queue.MaxSize() != queueSize {
+		t.Fatalf("expected %d got %d", queueSize, queue.MaxSize())
+	}
+	ctx := context.Background()
+	var i uint
+	for i = 0; i < queueSize; i++ {
+		if err := queue.AddLast(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := queue.TryAddLast(queueSize); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected queue is full error, got %v", err)
+	}
+	if queue.Size() != queueSize {
+		t.Fatalf("expected %d got %d", queueSize, queue.Size())
+	}
+	for i = 0; i < queueSize; i++ {
+		x, err := queue.RemoveFirst(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != i {
+			t.Fatalf("expected %d, got %d", i, x)
+		}
+	}
+	if _, err := queue.TryRemoveFirst(); !errors.Is(err, ErrQueueEmpty) {
+		t.Fatalf("expected queue is empty error, got %v", err)
+	}
+}
+
+func TestSPSCQueue_Batch(t *testing.T) {
+	queue, err := NewSPSCQueue[int](5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := queue.TryAddAll([]int{0, 1, 2}); n != 3 {
+		t.Fatalf("expected %d got %d", 3, n)
+	}
+	if n := queue.TryAddAll([]int{3, 4, 5, 6}); n != 2 {
+		t.Fatalf("expected %d got %d", 2, n)
+	}
+	dst := make([]int, 4)
+	if n := queue.TryRemoveInto(dst); n != 4 || !slices.Equal(dst, []int{0, 1, 2, 3}) {
+		t.Fatalf("expected %v got %v", []int{0, 1, 2, 3}, dst[:n])
+	}
+	if n := queue.TryAddAll([]int{5, 6, 7, 8, 9}); n != 4 {
+		t.Fatalf("expected %d got %d", 4, n)
+	}
+	dst = make([]int, 10)
+	if n := queue.TryRemoveInto(dst); n != 5 || !slices.Equal(dst[:n], []int{4, 5, 6, 7, 8}) {
+		t.Fatalf("expected %v got %v", []int{4, 5, 6, 7, 8}, dst[:n])
+	}
+}
+
+func TestSPSCQueue_Timeout(t *testing.T) {
+	queue, err := NewSPSCQueue[int](1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeout, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := queue.RemoveFirst(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if err := queue.TryAddLast(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.AddLast(timeout, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestSPSCQueue_ProducerConsumer(t *testing.T) {
+	var steps = 100_000
+	queue, err := NewSPSCQueue[int](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	producerResult := make(chan error, 1)
+	go func() {
+		for i := 0; i < steps; {
+			if i%3 == 0 {
+				batch := orderedIntArray(min(7, steps-i))
+				for j := range batch {
+					batch[j] += i
+				}
+				n := queue.TryAddAll(batch)
+				if n == 0 {
+					runtime.Gosched()
+				}
+				i += n
+				continue
+			}
+			if err := queue.AddLast(ctx, i); err != nil {
+				producerResult <- err
+				return
+			}
+			i++
+		}
+		producerResult <- nil
+	}()
+	dst := make([]int, 5)
+	for i := 0; i < steps; {
+		if i%2 == 0 {
+			n := queue.TryRemoveInto(dst)
+			if n == 0 {
+				runtime.Gosched()
+			}
+			for _, x := range dst[:n] {
+				if x != i {
+					t.Fatalf("expected %d, got %d", i, x)
+				}
+				i++
+			}
+			continue
+		}
+		x, err := queue.RemoveFirst(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != i {
+			t.Fatalf("expected %d, got %d", i, x)
+		}
+		i++
+	}
+	if err := <-producerResult; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -3,18 +3,50 @@ package collections
 import (
 	"context"
 	"errors"
+	"math"
+	"slices"
 	"sync"
+	"sync/atomic"
 
 	"golang.org/x/sync/semaphore"
 )
 
-// StandardQueueWithLimit an implementation of QueueWithLimit built on a Queue.
+// StandardQueueWithLimit an implementation of QueueWithLimit built on a Queue. The semaphores are only ever
+// acquired with TryAcquire: a cancelled Acquire may still hold permits it was granted, which would make a concurrent
+// TryAddLast or TryRemoveFirst fail spuriously. Blocked callers park on the notFull and notEmpty signals instead.
+//
+// Both semaphores have the largest possible size and the queue holds the permits which are not in use, so that
+// the max size can be changed by releasing or acquiring them.
+//
+// A weighted queue limits the total cost of its elements instead of their number: freeSlotsSemaphore still counts
+// elements, but fullSlotsSemaphore counts units of cost. Its blocked producers wait in line, see waiter.
 type StandardQueueWithLimit[T any] struct {
 	freeSlotsSemaphore *semaphore.Weighted
 	fullSlotsSemaphore *semaphore.Weighted
 	lock               *sync.Mutex
-	maxSize            uint
-	queue              Queue[T]
+	maxSize            atomic.Uint64
+	// cost of an element, nil if every element costs 1
+	cost func(T) int64
+	// totalCost of the elements in the queue, guarded by lock
+	totalCost int64
+	// shrinkDebt free slots SetMaxSize could not take away because they were occupied, they are taken as elements
+	// are removed, guarded by lock
+	shrinkDebt int64
+	// waiters blocked producers of a weighted queue in FIFO order, guarded by lock
+	waiters  []*waiter[T]
+	queue    fifo[T]
+	notEmpty *signal
+	notFull  *signal
+	// closed and closedNow are guarded by lock
+	closed    bool
+	closedNow bool
+	done      chan struct{}
+	doneOnce  sync.Once
+	// overflowPolicy and onDrop are guarded by lock
+	overflowPolicy OverflowPolicy
+	onDrop         func(T)
+	dropped        atomic.Uint64
+	observer       Observer
 }
 
 func NewLinkedQueueWithLimit[T any](maxSize uint) (*StandardQueueWithLimit[T], error) {
@@ -29,48 +61,405 @@ func NewSimpleArrayQueueWithLimit[T any](maxSize uint) (*StandardQueueWithLimit[
 	return newQueueWithLimit(maxSize, NewSimpleArrayQueueWithInitialCapacity[T](maxSize))
 }
 
-func newQueueWithLimit[T any](maxSize uint, queue Queue[T]) (*StandardQueueWithLimit[T], error) {
-	lock := new(sync.Mutex)
-	freeSlotsSemaphore := semaphore.NewWeighted(int64(maxSize))
-	if err := freeSlotsSemaphore.Acquire(context.Background(), int64(maxSize)); err != nil {
+func NewSegmentedQueueWithLimit[T any](maxSize uint) (*StandardQueueWithLimit[T], error) {
+	return newQueueWithLimit(maxSize, NewSegmentedQueue[T]())
+}
+
+// NewWeightedQueueWithLimit creates a queue limited by the total cost of its elements instead of their number, e.g.
+// by their size in bytes, and MaxSize returns the budget. The cost of an element must not change while it is in
+// the queue, negative costs count as zero. Adding an element which costs more than the whole budget fails with
+// ErrExceedsCapacity instead of blocking forever. Blocked producers are served in FIFO order, and TryAddLast fails
+// with ErrQueueFull while any of them waits.
+func NewWeightedQueueWithLimit[T any](budget int64, cost func(T) int64) (*StandardQueueWithLimit[T], error) {
+	if budget < 0 {
+		return nil, errors.New("budget must not be negative")
+	}
+	q, err := newQueueWithLimit[T](uint(budget), NewArrayQueue[T]())
+	if err != nil {
 		return nil, err
 	}
-	return &StandardQueueWithLimit[T]{
+	q.cost = cost
+	return q, nil
+}
+
+// waiter a producer of a weighted queue blocked until there is space for its elements. The elements are added on
+// its behalf, as soon as there is space for them and all producers which have waited longer have been served, so
+// that cheap elements cannot keep taking the space freed for an expensive one.
+type waiter[T any] struct {
+	values []T
+	cost   int64
+	// queued and added are guarded by lock
+	queued bool
+	added  bool
+}
+
+// fifo the part of Queue used by StandardQueueWithLimit, so that it can be built on other collections too.
+type fifo[T any] interface {
+	AddLast(T)
+	RemoveFirst() (T, error)
+	Size() uint
+}
+
+// oldestRemover a fifo which does not return the oldest element first implements it, so that OverflowDropOldest
+// can drop the oldest element.
+type oldestRemover[T any] interface {
+	RemoveOldest() (T, error)
+}
+
+func newQueueWithLimit[T any](maxSize uint, queue fifo[T]) (*StandardQueueWithLimit[T], error) {
+	if maxSize > math.MaxInt64 {
+		return nil, &QueueError{Op: "NewQueueWithLimit", Capacity: maxSize, Err: ErrExceedsCapacity}
+	}
+	lock := new(sync.Mutex)
+	freeSlotsSemaphore := semaphore.NewWeighted(math.MaxInt64)
+	freeSlotsSemaphore.TryAcquire(math.MaxInt64)
+	fullSlotsSemaphore := semaphore.NewWeighted(math.MaxInt64)
+	fullSlotsSemaphore.TryAcquire(math.MaxInt64 - int64(maxSize))
+	q := &StandardQueueWithLimit[T]{
 		freeSlotsSemaphore: freeSlotsSemaphore,
-		fullSlotsSemaphore: semaphore.NewWeighted(int64(maxSize)),
+		fullSlotsSemaphore: fullSlotsSemaphore,
 		lock:               lock,
-		maxSize:            maxSize,
 		queue:              queue,
-	}, nil
+		notEmpty:           newSignal(),
+		notFull:            newSignal(),
+		done:               make(chan struct{}),
+	}
+	q.maxSize.Store(uint64(maxSize))
+	return q, nil
 }
 
 func (q *StandardQueueWithLimit[T]) AddLast(ctx context.Context, value T) (err error) {
-	if err = q.fullSlotsSemaphore.Acquire(ctx, 1); err != nil {
+	defer func() {
+		_ = observeReject(q.observer, "AddLast", err)
+	}()
+	cost := q.costOf(value)
+	closed, exceeds, overflow := false, false, false
+	served, err := q.awaitSpace(ctx, "AddLast", []T{value}, cost, func() bool {
+		if closed, _ = q.closedState(); closed {
+			return true
+		}
+		// checked every time, SetMaxSize may have shrunk the budget
+		if exceeds = q.exceedsCapacity(cost); exceeds {
+			return true
+		}
+		if q.tryAcquireSpace(cost) {
+			return true
+		}
+		overflow = q.overflows()
+		return overflow
+	})
+	if served {
+		return nil
+	}
+	if err != nil {
 		return err
 	}
+	if closed {
+		return q.closedError("AddLast")
+	}
+	if exceeds {
+		return &QueueError{Op: "AddLast", Capacity: q.MaxSize(), Err: ErrExceedsCapacity}
+	}
+	if overflow {
+		return q.overflow("AddLast", value)
+	}
+	return q.addLast("AddLast", value)
+}
+
+// AddAll adds all elements to the end of the queue in one step, blocking until there is space for all of them or
+// until ctx is done. Either all elements are added or none, and the number of added elements is returned. Fails
+// with ErrExceedsCapacity if there are more elements than the queue can hold, or if they cost more than the budget
+// of a weighted queue. A full queue applies its overflow policy to all the elements together: OverflowReject fails
+// with ErrQueueFull and OverflowDropNewest drops them and fails with ErrDropped.
+func (q *StandardQueueWithLimit[T]) AddAll(ctx context.Context, values []T) (added int, err error) {
+	defer func() {
+		_ = observeReject(q.observer, "AddAll", err)
+	}()
+	cost := q.costOf(values...)
+	if cost > int64(q.MaxSize()) {
+		return 0, &QueueError{Op: "AddAll", Capacity: q.MaxSize(), Err: ErrExceedsCapacity}
+	}
+	closed, overflow := false, false
+	served, err := q.awaitSpace(ctx, "AddAll", values, cost, func() bool {
+		if closed, _ = q.closedState(); closed {
+			return true
+		}
+		if q.tryAcquireSpace(cost) {
+			return true
+		}
+		overflow = q.overflows()
+		return overflow
+	})
+	if served {
+		return len(values), nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if closed {
+		return 0, q.closedError("AddAll")
+	}
+	if overflow {
+		return 0, q.overflowBatch("AddAll", values...)
+	}
+	if err := q.addN("AddAll", values); err != nil {
+		return 0, err
+	}
+	return len(values), nil
+}
+
+// AddAllPartial adds elements to the end of the queue, each time as many as there is space for. Returns the number
+// of added elements, which is less than len(values) only together with an error. A weighted queue fails with
+// ErrExceedsCapacity when it reaches an element which costs more than the budget. Once the queue is full the
+// overflow policy applies to the rest of the elements: OverflowReject fails with ErrQueueFull and OverflowDropNewest
+// drops them and fails with ErrDropped.
+func (q *StandardQueueWithLimit[T]) AddAllPartial(ctx context.Context, values []T) (added int, err error) {
+	defer func() {
+		_ = observeReject(q.observer, "AddAllPartial", err)
+	}()
+	for added < len(values) {
+		n, served, closed, exceeds, overflow := 0, false, false, false, false
+		// a producer which has to wait joins the line with the next element only
+		served, err = q.awaitSpace(ctx, "AddAllPartial", values[added:added+1], q.costOf(values[added]), func() bool {
+			if closed, _ = q.closedState(); closed {
+				return true
+			}
+			if exceeds = q.exceedsCapacity(q.costOf(values[added])); exceeds {
+				return true
+			}
+			if n = q.tryAcquirePrefix(values[added:]); n > 0 {
+				return true
+			}
+			if q.tryAcquireSpace(q.costOf(values[added])) {
+				n = 1
+				return true
+			}
+			overflow = q.overflows()
+			return overflow
+		})
+		if served {
+			added++
+			continue
+		}
+		if err != nil {
+			return added, err
+		}
+		if closed {
+			return added, q.closedError("AddAllPartial")
+		}
+		if exceeds {
+			return added, &QueueError{Op: "AddAllPartial", Capacity: q.MaxSize(), Err: ErrExceedsCapacity}
+		}
+		if overflow {
+			return added, q.overflowBatch("AddAllPartial", values[added:]...)
+		}
+		if err := q.addN("AddAllPartial", values[added:added+n]); err != nil {
+			return added, err
+		}
+		added += n
+	}
+	return added, nil
+}
+
+// addLast adds an element to the queue, a free slot must have been acquired. Fails if the queue has been closed
+// in the meantime.
+func (q *StandardQueueWithLimit[T]) addLast(op string, value T) error {
+	return q.addN(op, []T{value})
+}
+
+// addN adds elements to the queue, free slots for all of them must have been acquired. Fails if the queue has been
+// closed in the meantime.
+func (q *StandardQueueWithLimit[T]) addN(op string, values []T) error {
+	cost := q.costOf(values...)
 	q.lock.Lock()
-	defer q.lock.Unlock()
-	q.queue.AddLast(value)
-	q.freeSlotsSemaphore.Release(1)
+	if q.closed {
+		q.releaseSlots(cost)
+		q.lock.Unlock()
+		return q.closedError(op)
+	}
+	for _, value := range values {
+		q.queue.AddLast(value)
+	}
+	q.totalCost += cost
+	q.freeSlotsSemaphore.Release(int64(len(values)))
+	size := q.queue.Size()
+	q.lock.Unlock()
+	q.notEmpty.notify()
+	if q.observer != nil {
+		q.observer.OnEnqueue(uint(len(values)), size)
+	}
 	return nil
 }
 
 func (q *StandardQueueWithLimit[T]) MaxSize() uint {
-	return q.maxSize
+	return uint(q.maxSize.Load())
+}
+
+// TotalCost returns the total cost of the elements in the queue, which is their number unless the queue is weighted.
+func (q *StandardQueueWithLimit[T]) TotalCost() int64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.totalCost
 }
 
 func (q *StandardQueueWithLimit[T]) RemoveFirst(ctx context.Context) (t T, err error) {
-	if err = q.freeSlotsSemaphore.Acquire(ctx, 1); err != nil {
+	var acquireErr error
+	err = q.await(ctx, "RemoveFirst", q.notEmpty, func() (ok bool) {
+		ok, acquireErr = q.tryAcquireElement("RemoveFirst")
+		return ok || acquireErr != nil
+	})
+	if err != nil {
 		return t, err
 	}
+	if acquireErr != nil {
+		return t, acquireErr
+	}
+	return q.removeFirst()
+}
+
+// RemoveUpTo blocks until there is at least one element or until ctx is done, and then removes as many elements
+// as are available, up to upTo, in one step. Once the queue is closed and empty it fails with ErrClosed.
+func (q *StandardQueueWithLimit[T]) RemoveUpTo(ctx context.Context, upTo int) ([]T, error) {
+	if upTo <= 0 {
+		return nil, nil
+	}
+	return q.removeAtLeast(ctx, "RemoveUpTo", 1, upTo)
+}
+
+// RemoveAtLeast blocks until there are at least atLeast elements or until ctx is done, and then removes as many
+// elements as are available, up to upTo, in one step. Once the queue is closed it returns the remaining elements
+// even if there are fewer than atLeast of them, and fails with ErrClosed when there are none. Fails with
+// ErrExceedsCapacity if atLeast is greater than the max size of a queue which is not weighted.
+func (q *StandardQueueWithLimit[T]) RemoveAtLeast(ctx context.Context, atLeast, upTo int) ([]T, error) {
+	return q.removeAtLeast(ctx, "RemoveAtLeast", atLeast, upTo)
+}
+
+func (q *StandardQueueWithLimit[T]) removeAtLeast(ctx context.Context, op string, atLeast, upTo int) ([]T, error) {
+	atLeast = max(atLeast, 0)
+	upTo = max(upTo, atLeast)
+	if q.cost == nil && uint(atLeast) > q.MaxSize() {
+		return nil, &QueueError{Op: op, Capacity: q.MaxSize(), Err: ErrExceedsCapacity}
+	}
+	n := 0
+	var acquireErr error
+	err := q.await(ctx, op, q.notEmpty, func() bool {
+		closed, closedNow := q.closedState()
+		if closedNow {
+			acquireErr = q.closedError(op)
+			return true
+		}
+		if q.freeSlotsSemaphore.TryAcquire(int64(atLeast)) {
+			n = atLeast + tryAcquireUpTo(q.freeSlotsSemaphore, int(q.Size())-atLeast, upTo-atLeast)
+			return true
+		}
+		if closed {
+			// fewer elements are left than requested and no more will be added
+			if n = tryAcquireUpTo(q.freeSlotsSemaphore, int(q.Size()), upTo); n == 0 {
+				acquireErr = q.closedError(op)
+			}
+			return true
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if acquireErr != nil {
+		return nil, acquireErr
+	}
+	return q.removeN(n)
+}
+
+// removeN removes n elements from the queue, all of them must have been acquired.
+func (q *StandardQueueWithLimit[T]) removeN(n int) ([]T, error) {
+	values := make([]T, 0, n)
+	var err error
 	q.lock.Lock()
-	defer q.lock.Unlock()
-	t, err = q.queue.RemoveFirst()
+	for len(values) < n {
+		var value T
+		if value, err = q.queue.RemoveFirst(); err != nil {
+			// the rest has not been removed, e.g. a SpillingQueue has failed to read it
+			q.freeSlotsSemaphore.Release(int64(n - len(values)))
+			break
+		}
+		values = append(values, value)
+	}
+	cost := q.costOf(values...)
+	q.totalCost -= cost
+	q.releaseSlots(cost)
+	if q.closed && q.queue.Size() == 0 {
+		q.closeDone()
+	}
+	size := q.queue.Size()
+	served := q.serveWaiters()
+	sizeServed := q.queue.Size()
+	q.lock.Unlock()
+	if len(values) > 0 {
+		q.notFull.notify()
+		if q.observer != nil {
+			q.observer.OnDequeue(uint(len(values)), size)
+		}
+	}
+	q.notifyServed(served, sizeServed)
+	return values, err
+}
+
+// tryAcquireElement acquires an element to be removed. Fails once the queue has been closed and is empty,
+// or immediately after CloseNow.
+func (q *StandardQueueWithLimit[T]) tryAcquireElement(op string) (bool, error) {
+	// closed is checked first, so that no element can be added after a failed TryAcquire
+	closed, closedNow := q.closedState()
+	if closedNow {
+		return false, q.closedError(op)
+	}
+	if q.freeSlotsSemaphore.TryAcquire(1) {
+		return true, nil
+	}
+	if closed {
+		return false, q.closedError(op)
+	}
+	return false, nil
+}
+
+func (q *StandardQueueWithLimit[T]) removeFirst() (T, error) {
+	return q.remove(q.queue.RemoveFirst)
+}
+
+// removeOldest removes the element which has been added first, which is the first one unless the queue keeps
+// another order.
+func (q *StandardQueueWithLimit[T]) removeOldest() (T, error) {
+	if queue, ok := q.queue.(oldestRemover[T]); ok {
+		return q.remove(queue.RemoveOldest)
+	}
+	return q.removeFirst()
+}
+
+// remove removes an element from the queue with the given function, an element must have been acquired.
+func (q *StandardQueueWithLimit[T]) remove(removeFrom func() (T, error)) (t T, err error) {
+	q.lock.Lock()
+	t, err = removeFrom()
 	if err != nil {
+		// the element has not been removed, e.g. a SpillingQueue has failed to read it
+		q.freeSlotsSemaphore.Release(1)
+		q.lock.Unlock()
 		return t, err
 	}
-	q.fullSlotsSemaphore.Release(1)
-	return t, err
+	cost := q.costOf(t)
+	q.totalCost -= cost
+	q.releaseSlots(cost)
+	if q.closed && q.queue.Size() == 0 {
+		q.closeDone()
+	}
+	size := q.queue.Size()
+	served := q.serveWaiters()
+	sizeServed := q.queue.Size()
+	q.lock.Unlock()
+	q.notFull.notify()
+	if q.observer != nil {
+		q.observer.OnDequeue(1, size)
+	}
+	q.notifyServed(served, sizeServed)
+	return t, nil
 }
 
 func (q *StandardQueueWithLimit[T]) Size() uint {
@@ -80,26 +469,410 @@ func (q *StandardQueueWithLimit[T]) Size() uint {
 }
 
 func (q *StandardQueueWithLimit[T]) TryAddLast(value T) (err error) {
-	if !q.freeSlotsSemaphore.TryAcquire(1) {
-		return errors.New("queue is full")
+	defer func() {
+		_ = observeReject(q.observer, "TryAddLast", err)
+	}()
+	if closed, _ := q.closedState(); closed {
+		return q.closedError("TryAddLast")
+	}
+	cost := q.costOf(value)
+	if q.exceedsCapacity(cost) {
+		return &QueueError{Op: "TryAddLast", Capacity: q.MaxSize(), Err: ErrExceedsCapacity}
+	}
+	if !q.tryAcquireSpace(cost) {
+		return q.overflow("TryAddLast", value)
+	}
+	return q.addLast("TryAddLast", value)
+}
+
+func (q *StandardQueueWithLimit[T]) TryRemoveFirst() (t T, err error) {
+	ok, err := q.tryAcquireElement("TryRemoveFirst")
+	if err != nil {
+		return t, err
+	}
+	if !ok {
+		return t, &QueueError{Op: "TryRemoveFirst", Capacity: q.MaxSize(), Err: ErrQueueEmpty}
+	}
+	return q.removeFirst()
+}
+
+// Close stops accepting new elements, blocked and later adds fail with ErrClosed. Consumers still receive
+// the remaining elements, and then fail with ErrClosed too.
+func (q *StandardQueueWithLimit[T]) Close() {
+	q.lock.Lock()
+	q.closed = true
+	if q.queue.Size() == 0 {
+		q.closeDone()
 	}
+	q.lock.Unlock()
+	q.notEmpty.notify()
+	q.notFull.notify()
+}
+
+// CloseNow closes the queue and makes all blocked and later operations fail with ErrClosed, even if there are
+// elements left. They can still be removed with Drain.
+func (q *StandardQueueWithLimit[T]) CloseNow() {
+	q.lock.Lock()
+	q.closed = true
+	q.closedNow = true
+	q.closeDone()
+	q.lock.Unlock()
+	q.notEmpty.notify()
+	q.notFull.notify()
+}
+
+// Done returns a channel which is closed once the queue has been closed and RemoveFirst will not return any more
+// elements.
+func (q *StandardQueueWithLimit[T]) Done() <-chan struct{} {
+	return q.done
+}
+
+// Drain removes elements until the queue is closed and empty or until ctx is done, and returns them.
+func (q *StandardQueueWithLimit[T]) Drain(ctx context.Context) []T {
+	var ts []T
+	_ = await(ctx, q.notEmpty, 0, func() bool {
+		closed, _ := q.closedState()
+		for q.freeSlotsSemaphore.TryAcquire(1) {
+			t, err := q.removeFirst()
+			if err != nil {
+				return true
+			}
+			ts = append(ts, t)
+		}
+		return closed
+	})
+	return ts
+}
+
+// SetMaxSize changes the max number of elements. Growing wakes up blocked producers right away. Shrinking below
+// the current size rejects new elements until consumers remove enough of them. It never blocks, so ctx is unused,
+// but it is accepted for symmetry with queues which have to wait.
+func (q *StandardQueueWithLimit[T]) SetMaxSize(_ context.Context, maxSize uint) error {
+	if maxSize > math.MaxInt64 {
+		return &QueueError{Op: "SetMaxSize", Capacity: q.MaxSize(), Err: ErrExceedsCapacity}
+	}
+	q.lock.Lock()
+	oldMaxSize := int64(q.maxSize.Load())
+	q.maxSize.Store(uint64(maxSize))
+	if diff := int64(maxSize) - oldMaxSize; diff > 0 {
+		q.releaseSlots(diff)
+	} else if diff < 0 {
+		taken := tryAcquireUpTo(q.fullSlotsSemaphore, int(oldMaxSize)-int(q.queue.Size()), int(-diff))
+		q.shrinkDebt += -diff - int64(taken)
+	}
+	served := q.serveWaiters()
+	size := q.queue.Size()
+	q.lock.Unlock()
+	q.notFull.notify()
+	q.notifyServed(served, size)
+	return nil
+}
+
+// SetObserver sets the observer notified about events of the queue. It must be set before the queue is shared
+// between goroutines.
+func (q *StandardQueueWithLimit[T]) SetObserver(observer Observer) {
+	q.observer = observer
+}
+
+// await calls try until it succeeds like the await function, and reports to the observer if it has to wait.
+func (q *StandardQueueWithLimit[T]) await(ctx context.Context, op string, s *signal, try func() bool) error {
+	if try() {
+		return nil
+	}
+	return observeWait(q.observer, op, func() error {
+		return await(ctx, s, 0, try)
+	})
+}
+
+// awaitSpace calls try until it succeeds like await. A producer of a weighted queue which has to wait joins the line
+// of waiters with values instead of acquiring space itself, and true is returned if they have been added.
+func (q *StandardQueueWithLimit[T]) awaitSpace(ctx context.Context, op string, values []T, cost int64, try func() bool) (bool, error) {
+	var w *waiter[T]
+	err := q.await(ctx, op, q.notFull, func() bool {
+		if w != nil && q.served(w) {
+			return true
+		}
+		if try() {
+			return true
+		}
+		if q.cost != nil {
+			w = q.join(w, values, cost)
+		}
+		return false
+	})
+	// the elements may have been added even if try has given up in the meantime
+	if w != nil && q.leave(w) {
+		return true, nil
+	}
+	return false, err
+}
+
+// join puts a producer at the end of the line unless it is there already, or unless the policy is not
+// OverflowBlock. Returns the waiter of the producer, creating it if w is nil.
+func (q *StandardQueueWithLimit[T]) join(w *waiter[T], values []T, cost int64) *waiter[T] {
+	q.lock.Lock()
+	if q.overflowPolicy != OverflowBlock {
+		q.lock.Unlock()
+		return w
+	}
+	if w == nil {
+		w = &waiter[T]{values: values, cost: cost}
+	}
+	if !w.queued {
+		w.queued = true
+		q.waiters = append(q.waiters, w)
+	}
+	// space may have been freed since the producer has tried to acquire it
+	served := q.serveWaiters()
+	size := q.queue.Size()
+	q.lock.Unlock()
+	q.notifyServed(served, size)
+	return w
+}
+
+// leave removes a producer from the line, and returns true if its elements have been added already.
+func (q *StandardQueueWithLimit[T]) leave(w *waiter[T]) bool {
+	q.lock.Lock()
+	if w.added {
+		q.lock.Unlock()
+		return true
+	}
+	if w.queued {
+		w.queued = false
+		q.waiters = slices.DeleteFunc(q.waiters, func(other *waiter[T]) bool {
+			return other == w
+		})
+	}
+	// the next producer may fit now
+	served := q.serveWaiters()
+	size := q.queue.Size()
+	q.lock.Unlock()
+	q.notifyServed(served, size)
+	return false
+}
+
+func (q *StandardQueueWithLimit[T]) served(w *waiter[T]) bool {
 	q.lock.Lock()
 	defer q.lock.Unlock()
-	q.queue.AddLast(value)
-	q.fullSlotsSemaphore.Release(1)
-	return err
+	return w.added
 }
 
-func (q *StandardQueueWithLimit[T]) TryRemoveFirst() (t T, err error) {
-	if !q.freeSlotsSemaphore.TryAcquire(1) {
-		return t, errors.New("queue is empty")
+func (q *StandardQueueWithLimit[T]) hasWaiters() bool {
+	if q.cost == nil {
+		return false
 	}
 	q.lock.Lock()
 	defer q.lock.Unlock()
-	first, err := q.queue.RemoveFirst()
-	if err != nil {
-		return t, err
+	return len(q.waiters) > 0
+}
+
+// serveWaiters adds the elements of waiting producers in FIFO order as long as there is space for them, and returns
+// their number. The lock must be held.
+func (q *StandardQueueWithLimit[T]) serveWaiters() int {
+	n := 0
+	for len(q.waiters) > 0 && !q.closed {
+		w := q.waiters[0]
+		if !q.fullSlotsSemaphore.TryAcquire(w.cost) {
+			break
+		}
+		q.waiters = slices.Delete(q.waiters, 0, 1)
+		w.queued, w.added = false, true
+		for _, value := range w.values {
+			q.queue.AddLast(value)
+		}
+		q.totalCost += w.cost
+		q.freeSlotsSemaphore.Release(int64(len(w.values)))
+		n += len(w.values)
+	}
+	return n
+}
+
+// notifyServed wakes up the producers served by serveWaiters and consumers, and reports the n added elements to
+// the observer. It must be called without holding the lock.
+func (q *StandardQueueWithLimit[T]) notifyServed(n int, size uint) {
+	if n == 0 {
+		return
 	}
-	q.fullSlotsSemaphore.Release(1)
-	return first, err
+	q.notEmpty.notify()
+	q.notFull.notify()
+	if q.observer != nil {
+		q.observer.OnEnqueue(uint(n), size)
+	}
+}
+
+// SetOverflowPolicy sets what happens to elements added to the queue when it is full.
+func (q *StandardQueueWithLimit[T]) SetOverflowPolicy(policy OverflowPolicy) {
+	q.lock.Lock()
+	q.overflowPolicy = policy
+	if policy != OverflowBlock {
+		// producers wait in line only to block
+		for _, w := range q.waiters {
+			w.queued = false
+		}
+		q.waiters = nil
+	}
+	q.lock.Unlock()
+	// blocked producers follow the new policy
+	q.notFull.notify()
+}
+
+// SetOnDrop sets a function called with every element dropped by the overflow policy. It is called by the goroutine
+// which has added an element, without holding any lock.
+func (q *StandardQueueWithLimit[T]) SetOnDrop(onDrop func(T)) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.onDrop = onDrop
+}
+
+// Dropped returns the number of elements dropped by the overflow policy so far.
+func (q *StandardQueueWithLimit[T]) Dropped() uint64 {
+	return q.dropped.Load()
+}
+
+func (q *StandardQueueWithLimit[T]) policy() OverflowPolicy {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.overflowPolicy
+}
+
+// tryAcquireSpace acquires space for elements of the given cost. If there is not enough of it and the policy is
+// OverflowDropOldest, it removes the oldest elements until there is.
+func (q *StandardQueueWithLimit[T]) tryAcquireSpace(cost int64) bool {
+	if q.hasWaiters() {
+		return false
+	}
+	if q.fullSlotsSemaphore.TryAcquire(cost) {
+		return true
+	}
+	if q.policy() != OverflowDropOldest {
+		return false
+	}
+	// the space of a removed element may be taken by another producer, so it is acquired again every time
+	for q.freeSlotsSemaphore.TryAcquire(1) {
+		t, err := q.removeOldest()
+		if err != nil {
+			return false
+		}
+		q.drop(t)
+		if q.fullSlotsSemaphore.TryAcquire(cost) {
+			return true
+		}
+	}
+	return false
+}
+
+// overflows tells whether elements which do not fit are handled by the policy right away instead of waiting.
+func (q *StandardQueueWithLimit[T]) overflows() bool {
+	policy := q.policy()
+	return policy == OverflowReject || policy == OverflowDropNewest
+}
+
+// overflow handles elements which do not fit into the queue, they are dropped if the policy is OverflowDropNewest
+// and rejected with ErrQueueFull otherwise.
+func (q *StandardQueueWithLimit[T]) overflow(op string, values ...T) error {
+	if q.policy() != OverflowDropNewest {
+		return &QueueError{Op: op, Capacity: q.MaxSize(), Err: ErrQueueFull}
+	}
+	q.drop(values...)
+	return nil
+}
+
+// overflowBatch handles elements of a batch like overflow, but fails with ErrDropped if they have been dropped, so
+// that they cannot be mistaken for added ones.
+func (q *StandardQueueWithLimit[T]) overflowBatch(op string, values ...T) error {
+	if err := q.overflow(op, values...); err != nil {
+		return err
+	}
+	return &QueueError{Op: op, Capacity: q.MaxSize(), Err: ErrDropped}
+}
+
+func (q *StandardQueueWithLimit[T]) drop(values ...T) {
+	q.lock.Lock()
+	onDrop := q.onDrop
+	q.lock.Unlock()
+	q.dropped.Add(uint64(len(values)))
+	if onDrop != nil {
+		for _, value := range values {
+			onDrop(value)
+		}
+	}
+}
+
+// releaseSlots makes slots free again, unless they are owed to a previous shrink. The lock must be held.
+func (q *StandardQueueWithLimit[T]) releaseSlots(n int64) {
+	paid := min(n, q.shrinkDebt)
+	q.shrinkDebt -= paid
+	if n > paid {
+		q.fullSlotsSemaphore.Release(n - paid)
+	}
+}
+
+// costOf returns the total cost of the given elements, saturating instead of overflowing.
+func (q *StandardQueueWithLimit[T]) costOf(values ...T) int64 {
+	if q.cost == nil {
+		return int64(len(values))
+	}
+	var total int64
+	for _, value := range values {
+		cost := max(q.cost(value), 0)
+		if cost > math.MaxInt64-total {
+			return math.MaxInt64
+		}
+		total += cost
+	}
+	return total
+}
+
+// exceedsCapacity tells whether an element of a weighted queue costs more than the whole budget. Other queues block
+// instead, so that SetMaxSize(ctx, 0) can be used to stop producers.
+func (q *StandardQueueWithLimit[T]) exceedsCapacity(cost int64) bool {
+	return q.cost != nil && cost > int64(q.MaxSize())
+}
+
+// tryAcquirePrefix acquires space for as many elements from the beginning of values as it can, and returns their
+// number.
+func (q *StandardQueueWithLimit[T]) tryAcquirePrefix(values []T) int {
+	if q.cost == nil {
+		return tryAcquireUpTo(q.fullSlotsSemaphore, int(q.MaxSize())-int(q.Size()), len(values))
+	}
+	if q.hasWaiters() {
+		return 0
+	}
+	n := 0
+	for n < len(values) && q.fullSlotsSemaphore.TryAcquire(q.costOf(values[n])) {
+		n++
+	}
+	return n
+}
+
+// tryAcquireUpTo acquires as many permits as it can, up to n. It starts with the estimated number of available
+// permits and halves the number on every failure, so it takes a few steps only.
+func tryAcquireUpTo(s *semaphore.Weighted, estimate, n int) int {
+	acquired := 0
+	for k := min(max(estimate, 1), n); k > 0; {
+		if s.TryAcquire(int64(k)) {
+			acquired += k
+			k = min(k, n-acquired)
+		} else {
+			k /= 2
+		}
+	}
+	return acquired
+}
+
+func (q *StandardQueueWithLimit[T]) closedState() (closed, closedNow bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.closed, q.closedNow
+}
+
+func (q *StandardQueueWithLimit[T]) closedError(op string) error {
+	return &QueueError{Op: op, Capacity: q.MaxSize(), Err: ErrClosed}
+}
+
+// closeDone closes the done channel, it may be called many times.
+func (q *StandardQueueWithLimit[T]) closeDone() {
+	q.doneOnce.Do(func() {
+		close(q.done)
+	})
 }
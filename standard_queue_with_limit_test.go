@@ -0,0 +1,435 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStandardQueueWithLimit_AddAll(t *testing.T) {
+	q, err := NewArrayQueueWithLimit[int](4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if n, err := q.AddAll(ctx, []int{0, 1, 2, 3, 4}); n != 0 || !errors.Is(err, ErrExceedsCapacity) {
+		t.Fatalf("expected exceeds capacity error, got %d %v", n, err)
+	}
+	if n, err := q.AddAll(ctx, []int{0, 1, 2}); n != 3 || err != nil {
+		t.Fatalf("expected %d got %d %v", 3, n, err)
+	}
+
+	// there is space for one element only, so nothing is added
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if n, err := q.AddAll(timeout, []int{3, 4}); n != 0 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded error, got %d %v", n, err)
+	}
+	if q.Size() != 3 {
+		t.Fatalf("expected %d got %d", 3, q.Size())
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_, _ = q.TryRemoveFirst()
+	}()
+	if n, err := q.AddAll(ctx, []int{3, 4}); n != 2 || err != nil {
+		t.Fatalf("expected %d got %d %v", 2, n, err)
+	}
+	if values, err := q.RemoveUpTo(ctx, 10); err != nil || !slices.Equal(values, []int{1, 2, 3, 4}) {
+		t.Fatalf("expected %v got %v %v", []int{1, 2, 3, 4}, values, err)
+	}
+}
+
+func TestStandardQueueWithLimit_AddAllPartial(t *testing.T) {
+	q, err := NewLinkedQueueWithLimit[int](3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	values := make([]int, 100)
+	for i := range values {
+		values[i] = i
+	}
+	done := make(chan []int)
+	go func() {
+		var removed []int
+		for len(removed) < len(values) {
+			batch, err := q.RemoveUpTo(ctx, 2)
+			if err != nil {
+				t.Error(err)
+				break
+			}
+			removed = append(removed, batch...)
+		}
+		done <- removed
+	}()
+	if n, err := q.AddAllPartial(ctx, values); n != len(values) || err != nil {
+		t.Fatalf("expected %d got %d %v", len(values), n, err)
+	}
+	if removed := <-done; !slices.Equal(removed, values) {
+		t.Fatalf("expected %v got %v", values, removed)
+	}
+
+	if err := q.TryAddLast(0); err != nil {
+		t.Fatal(err)
+	}
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if n, err := q.AddAllPartial(timeout, []int{1, 2, 3, 4}); n != 2 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %d and deadline exceeded error, got %d %v", 2, n, err)
+	}
+}
+
+func TestStandardQueueWithLimit_RemoveUpTo(t *testing.T) {
+	q, err := NewArrayQueueWithLimit[int](10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := q.AddAll(ctx, []int{0, 1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if values, err := q.RemoveUpTo(ctx, 2); err != nil || !slices.Equal(values, []int{0, 1}) {
+		t.Fatalf("expected %v got %v %v", []int{0, 1}, values, err)
+	}
+	if values, err := q.RemoveUpTo(ctx, 5); err != nil || !slices.Equal(values, []int{2}) {
+		t.Fatalf("expected %v got %v %v", []int{2}, values, err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = q.TryAddLast(3)
+	}()
+	if values, err := q.RemoveUpTo(ctx, 5); err != nil || !slices.Equal(values, []int{3}) {
+		t.Fatalf("expected %v got %v %v", []int{3}, values, err)
+	}
+
+	q.Close()
+	if _, err := q.RemoveUpTo(ctx, 5); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected queue is closed error, got %v", err)
+	}
+}
+
+func TestStandardQueueWithLimit_RemoveAtLeast(t *testing.T) {
+	q, err := NewArrayQueueWithLimit[int](10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := q.RemoveAtLeast(ctx, 11, 20); !errors.Is(err, ErrExceedsCapacity) {
+		t.Fatalf("expected exceeds capacity error, got %v", err)
+	}
+	if _, err := q.AddAll(ctx, []int{0, 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.RemoveAtLeast(timeout, 3, 5); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded error, got %v", err)
+	}
+
+	go func() {
+		for i := 2; i < 6; i++ {
+			time.Sleep(time.Millisecond)
+			_ = q.TryAddLast(i)
+		}
+	}()
+	values, err := q.RemoveAtLeast(ctx, 3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) < 3 || len(values) > 4 || !slices.Equal(values, []int{0, 1, 2, 3}[:len(values)]) {
+		t.Fatalf("expected 3 or 4 first elements, got %v", values)
+	}
+
+	// once closed, the rest is returned even if it is fewer elements than requested
+	for q.Size()+uint(len(values)) < 6 {
+		time.Sleep(time.Millisecond)
+	}
+	q.Close()
+	rest, err := q.RemoveAtLeast(ctx, 5, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all := append(values, rest...); !slices.Equal(all, []int{0, 1, 2, 3, 4, 5}) {
+		t.Fatalf("expected %v got %v", []int{0, 1, 2, 3, 4, 5}, all)
+	}
+	if _, err := q.RemoveAtLeast(ctx, 5, 10); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected queue is closed error, got %v", err)
+	}
+}
+
+func newWeightedTestQueue(t *testing.T, budget int64) *StandardQueueWithLimit[string] {
+	q, err := NewWeightedQueueWithLimit[string](budget, func(s string) int64 { return int64(len(s)) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func TestStandardQueueWithLimit_Weighted(t *testing.T) {
+	q := newWeightedTestQueue(t, 10)
+	ctx := context.Background()
+	for _, s := range []string{"aaaa", "bbbb", ""} {
+		if err := q.TryAddLast(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if q.Size() != 3 {
+		t.Fatalf("expected %d got %d", 3, q.Size())
+	}
+	if q.TotalCost() != 8 {
+		t.Fatalf("expected %d got %d", 8, q.TotalCost())
+	}
+	if err := q.TryAddLast("ccc"); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected queue is full error, got %v", err)
+	}
+	if err := q.TryAddLast("cc"); err != nil {
+		t.Fatal(err)
+	}
+
+	// removing a single element frees enough budget for the blocked producer
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_, _ = q.TryRemoveFirst()
+	}()
+	if err := q.AddLast(ctx, "dddd"); err != nil {
+		t.Fatal(err)
+	}
+	if q.TotalCost() != 10 {
+		t.Fatalf("expected %d got %d", 10, q.TotalCost())
+	}
+	values, err := q.RemoveUpTo(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(values, []string{"bbbb", "", "cc", "dddd"}) {
+		t.Fatalf("expected %v got %v", []string{"bbbb", "", "cc", "dddd"}, values)
+	}
+	if q.TotalCost() != 0 {
+		t.Fatalf("expected %d got %d", 0, q.TotalCost())
+	}
+}
+
+func TestStandardQueueWithLimit_WeightedExceedsCapacity(t *testing.T) {
+	q := newWeightedTestQueue(t, 4)
+	ctx := context.Background()
+	if err := q.TryAddLast("aaaaa"); !errors.Is(err, ErrExceedsCapacity) {
+		t.Fatalf("expected exceeds capacity error, got %v", err)
+	}
+	// an element larger than the budget fails even if the queue is empty, instead of blocking forever
+	if err := q.AddLast(ctx, "aaaaa"); !errors.Is(err, ErrExceedsCapacity) {
+		t.Fatalf("expected exceeds capacity error, got %v", err)
+	}
+	if n, err := q.AddAll(ctx, []string{"aa", "bbb"}); n != 0 || !errors.Is(err, ErrExceedsCapacity) {
+		t.Fatalf("expected exceeds capacity error, got %d %v", n, err)
+	}
+	if n, err := q.AddAllPartial(ctx, []string{"aa", "bb", "ccccc"}); n != 2 || !errors.Is(err, ErrExceedsCapacity) {
+		t.Fatalf("expected exceeds capacity error after %d elements, got %d %v", 2, n, err)
+	}
+	if q.TotalCost() != 4 {
+		t.Fatalf("expected %d got %d", 4, q.TotalCost())
+	}
+
+	// a producer blocked on an element which fits is woken up when the budget shrinks below it
+	errs := make(chan error)
+	go func() {
+		errs <- q.AddLast(ctx, "ddd")
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if err := q.SetMaxSize(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; !errors.Is(err, ErrExceedsCapacity) {
+		t.Fatalf("expected exceeds capacity error, got %v", err)
+	}
+}
+
+func TestStandardQueueWithLimit_WeightedAddAllPartial(t *testing.T) {
+	q := newWeightedTestQueue(t, 6)
+	ctx := context.Background()
+	values := []string{"aaa", "bb", "cccc", "d", "eee"}
+	removed := make(chan []string)
+	go func() {
+		var result []string
+		for len(result) < len(values) {
+			x, err := q.RemoveFirst(ctx)
+			if err != nil {
+				t.Error(err)
+				break
+			}
+			result = append(result, x)
+		}
+		removed <- result
+	}()
+	if n, err := q.AddAllPartial(ctx, values); n != len(values) || err != nil {
+		t.Fatalf("expected %d got %d %v", len(values), n, err)
+	}
+	if result := <-removed; !slices.Equal(result, values) {
+		t.Fatalf("expected %v got %v", values, result)
+	}
+}
+
+func TestStandardQueueWithLimit_WeightedLargeElement(t *testing.T) {
+	q := newWeightedTestQueue(t, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := make(chan struct{})
+	var producers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		producers.Go(func() {
+			for q.AddLast(ctx, "a") == nil {
+			}
+		})
+	}
+	// a slow consumer lets the small producers refill the queue as soon as an element is removed
+	go func() {
+		defer close(stopped)
+		for {
+			if _, err := q.RemoveFirst(ctx); err != nil {
+				return
+			}
+			time.Sleep(100 * time.Microsecond)
+		}
+	}()
+	for q.TotalCost() < 10 {
+		time.Sleep(time.Millisecond)
+	}
+	// the space freed by the consumer is reserved for the large element instead of being taken by the small ones
+	timeout, cancelTimeout := context.WithTimeout(ctx, time.Second)
+	defer cancelTimeout()
+	if err := q.AddLast(timeout, "bbbbbbbbbb"); err != nil {
+		t.Fatalf("expected the large element to be added, got %v", err)
+	}
+	cancel()
+	producers.Wait()
+	<-stopped
+}
+
+func TestStandardQueueWithLimit_OverflowPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   OverflowPolicy
+		err      error
+		expected []int
+		dropped  []int
+	}{
+		{"reject", OverflowReject, ErrQueueFull, []int{0, 1, 2}, nil},
+		{"drop oldest", OverflowDropOldest, nil, []int{1, 2, 3}, []int{0}},
+		{"drop newest", OverflowDropNewest, nil, []int{0, 1, 2}, []int{3}},
+	}
+	for _, test := range tests {
+		for _, op := range []string{"AddLast", "TryAddLast"} {
+			t.Run(test.name+" "+op, func(t *testing.T) {
+				q, err := NewArrayQueueWithLimit[int](3)
+				if err != nil {
+					t.Fatal(err)
+				}
+				q.SetOverflowPolicy(test.policy)
+				var dropped []int
+				q.SetOnDrop(func(x int) {
+					dropped = append(dropped, x)
+				})
+				ctx := context.Background()
+				for i := 0; i < 3; i++ {
+					if err := q.AddLast(ctx, i); err != nil {
+						t.Fatal(err)
+					}
+				}
+				// AddLast does not block, so no timeout is needed
+				if op == "AddLast" {
+					err = q.AddLast(ctx, 3)
+				} else {
+					err = q.TryAddLast(3)
+				}
+				if !errors.Is(err, test.err) {
+					t.Fatalf("expected %v got %v", test.err, err)
+				}
+				if values, _ := q.RemoveUpTo(ctx, 10); !slices.Equal(values, test.expected) {
+					t.Fatalf("expected %v got %v", test.expected, values)
+				}
+				if !slices.Equal(dropped, test.dropped) {
+					t.Fatalf("expected %v got %v", test.dropped, dropped)
+				}
+				if q.Dropped() != uint64(len(test.dropped)) {
+					t.Fatalf("expected %d got %d", len(test.dropped), q.Dropped())
+				}
+			})
+		}
+	}
+}
+
+func TestStandardQueueWithLimit_OverflowPolicyBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   OverflowPolicy
+		partial  bool
+		added    int
+		err      error
+		expected []int
+	}{
+		{"reject", OverflowReject, false, 0, ErrQueueFull, []int{0, 1}},
+		{"reject", OverflowReject, true, 1, ErrQueueFull, []int{0, 1, 2}},
+		{"drop oldest", OverflowDropOldest, false, 2, nil, []int{1, 2, 3}},
+		{"drop oldest", OverflowDropOldest, true, 2, nil, []int{1, 2, 3}},
+		{"drop newest", OverflowDropNewest, false, 0, ErrDropped, []int{0, 1}},
+		{"drop newest", OverflowDropNewest, true, 1, ErrDropped, []int{0, 1, 2}},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s partial %t", test.name, test.partial), func(t *testing.T) {
+			q, err := NewArrayQueueWithLimit[int](3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q.SetOverflowPolicy(test.policy)
+			ctx := context.Background()
+			if _, err := q.AddAll(ctx, []int{0, 1}); err != nil {
+				t.Fatal(err)
+			}
+			var added int
+			if test.partial {
+				added, err = q.AddAllPartial(ctx, []int{2, 3})
+			} else {
+				added, err = q.AddAll(ctx, []int{2, 3})
+			}
+			if added != test.added || !errors.Is(err, test.err) {
+				t.Fatalf("expected %d %v got %d %v", test.added, test.err, added, err)
+			}
+			if values, _ := q.RemoveUpTo(ctx, 10); !slices.Equal(values, test.expected) {
+				t.Fatalf("expected %v got %v", test.expected, values)
+			}
+		})
+	}
+}
+
+func TestStandardQueueWithLimit_SetOverflowPolicyWakesProducers(t *testing.T) {
+	q, err := NewArrayQueueWithLimit[int](1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := q.AddLast(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error)
+	go func() {
+		errs <- q.AddLast(ctx, 1)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	q.SetOverflowPolicy(OverflowDropOldest)
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if x, _ := q.TryRemoveFirst(); x != 1 {
+		t.Fatalf("expected %d got %d", 1, x)
+	}
+	if q.Dropped() != 1 {
+		t.Fatalf("expected %d got %d", 1, q.Dropped())
+	}
+}
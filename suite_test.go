@@ -0,0 +1,165 @@
+package collections_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/viger-pro/go-collections"
+	"github.com/viger-pro/go-collections/collectionstest"
+)
+
+func TestQueueSuite(t *testing.T) {
+	tests := []struct {
+		name     string
+		newQueue func() collections.Queue[int]
+	}{
+		{"linked queue", func() collections.Queue[int] { return collections.NewLinkedQueue[int]() }},
+		{"array queue", func() collections.Queue[int] { return collections.NewArrayQueue[int]() }},
+		{"simple array queue", func() collections.Queue[int] { return collections.NewSimpleArrayQueue[int]() }},
+		{"segmented queue", func() collections.Queue[int] { return collections.NewSegmentedQueueWithSegmentSize[int](8) }},
+		{"array deque", func() collections.Queue[int] { return collections.NewArrayDeque[int]() }},
+		{"linked deque", func() collections.Queue[int] { return collections.NewLinkedDeque[int]() }},
+		{"concurrent linked queue", func() collections.Queue[int] { return collections.NewConcurrentLinkedQueue[int]() }},
+		// the buffer never overwrites elements, the suite stores fewer of them
+		{"circular buffer", func() collections.Queue[int] {
+			q, err := collections.NewCircularBuffer[int](1 << 16)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return q
+		}},
+		{"spilling queue", func() collections.Queue[int] {
+			q := collections.NewSpillingQueue[int](t.TempDir(), 8, collections.GobCodec[int]{})
+			t.Cleanup(func() { _ = q.Close() })
+			return q
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			collectionstest.RunQueueSuite(t, test.newQueue)
+		})
+	}
+}
+
+// TestQueueWithLimitSuite runs the suite over all implementations except SPSCQueue, which allows only one producer
+// and one consumer.
+func TestQueueWithLimitSuite(t *testing.T) {
+	tests := []struct {
+		name     string
+		newQueue func(maxSize uint) (collections.QueueWithLimit[uint], error)
+	}{
+		{"standard queue on linked queue", func(maxSize uint) (collections.QueueWithLimit[uint], error) {
+			return collections.NewLinkedQueueWithLimit[uint](maxSize)
+		}},
+		{"standard queue on array queue", func(maxSize uint) (collections.QueueWithLimit[uint], error) {
+			return collections.NewArrayQueueWithLimit[uint](maxSize)
+		}},
+		{"standard queue on simple array queue", func(maxSize uint) (collections.QueueWithLimit[uint], error) {
+			return collections.NewSimpleArrayQueueWithLimit[uint](maxSize)
+		}},
+		{"standard queue on segmented queue", func(maxSize uint) (collections.QueueWithLimit[uint], error) {
+			return collections.NewSegmentedQueueWithLimit[uint](maxSize)
+		}},
+		{"lock-free queue", func(maxSize uint) (collections.QueueWithLimit[uint], error) {
+			return collections.NewLockFreeQueueWithLimit[uint](maxSize)
+		}},
+		{"channelled queue", func(maxSize uint) (collections.QueueWithLimit[uint], error) {
+			return collections.NewChannelledQueueWithLimit[uint](maxSize), nil
+		}},
+		{"standard queue on spilling queue", func(maxSize uint) (collections.QueueWithLimit[uint], error) {
+			q, err := collections.NewSpillingQueueWithLimit[uint](t.TempDir(), 8, maxSize, collections.GobCodec[uint]{})
+			if err != nil {
+				return nil, err
+			}
+			t.Cleanup(func() { _ = q.Discard() })
+			return q, nil
+		}},
+		// every element costs 1, so the budget is the max number of elements
+		{"weighted queue", func(maxSize uint) (collections.QueueWithLimit[uint], error) {
+			return collections.NewWeightedQueueWithLimit[uint](int64(maxSize), func(uint) int64 { return 1 })
+		}},
+		// a single key makes the fair queue FIFO
+		{"fair queue", func(maxSize uint) (collections.QueueWithLimit[uint], error) {
+			return collections.NewFairQueue[int, uint](maxSize, maxSize, func(uint) int { return 0 })
+		}},
+		{"durable queue", func(maxSize uint) (collections.QueueWithLimit[uint], error) {
+			q, err := collections.NewDurableQueue[uint](t.TempDir(), maxSize, collections.GobCodec[uint]{},
+				collections.DurableQueueOptions{SyncPolicy: collections.SyncInterval(time.Millisecond)})
+			if err == nil {
+				t.Cleanup(func() { _ = q.Close() })
+			}
+			return q, err
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			collectionstest.RunQueueWithLimitSuite(t, test.newQueue)
+		})
+	}
+}
+
+func TestClosableQueueWithLimitSuite(t *testing.T) {
+	tests := []struct {
+		name     string
+		newQueue func(maxSize uint) (collections.ClosableQueueWithLimit[uint], error)
+	}{
+		{"standard queue on linked queue", func(maxSize uint) (collections.ClosableQueueWithLimit[uint], error) {
+			return collections.NewLinkedQueueWithLimit[uint](maxSize)
+		}},
+		{"standard queue on array queue", func(maxSize uint) (collections.ClosableQueueWithLimit[uint], error) {
+			return collections.NewArrayQueueWithLimit[uint](maxSize)
+		}},
+		{"channelled queue", func(maxSize uint) (collections.ClosableQueueWithLimit[uint], error) {
+			return collections.NewChannelledQueueWithLimit[uint](maxSize), nil
+		}},
+		// the suite adds elements in increasing order, so the priority order is the same as FIFO
+		{"priority queue", func(maxSize uint) (collections.ClosableQueueWithLimit[uint], error) {
+			return collections.NewPriorityQueueWithLimit[uint](maxSize)
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			collectionstest.RunClosableQueueWithLimitSuite(t, test.newQueue)
+		})
+	}
+}
+
+func TestHeapSuite(t *testing.T) {
+	collectionstest.RunHeapSuite(t, func(initialCapacity int) collectionstest.Heap[int] {
+		return collections.NewHeap[int](initialCapacity)
+	})
+}
+
+func TestCheckQueueWithLimitHistory_Errors(t *testing.T) {
+	full := &collections.QueueError{Op: "AddLast", Capacity: 1, Err: collections.ErrQueueFull}
+	empty := &collections.QueueError{Op: "RemoveFirst", Capacity: 1, Err: collections.ErrQueueEmpty}
+	tests := []struct {
+		name         string
+		history      []collectionstest.Operation
+		linearizable bool
+	}{
+		{"try add to full queue", []collectionstest.Operation{
+			{Kind: collectionstest.AddLast, Value: 1, Call: 1, Return: 2},
+			{Kind: collectionstest.TryAddLast, Value: 2, Err: full, Call: 3, Return: 4},
+		}, true},
+		// blocking operations wait instead of failing
+		{"add to full queue", []collectionstest.Operation{
+			{Kind: collectionstest.AddLast, Value: 1, Call: 1, Return: 2},
+			{Kind: collectionstest.AddLast, Value: 2, Err: full, Call: 3, Return: 4},
+		}, false},
+		{"try remove from empty queue", []collectionstest.Operation{
+			{Kind: collectionstest.TryRemoveFirst, Err: empty, Call: 1, Return: 2},
+		}, true},
+		{"remove from empty queue", []collectionstest.Operation{
+			{Kind: collectionstest.RemoveFirst, Err: empty, Call: 1, Return: 2},
+		}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := collectionstest.CheckQueueWithLimitHistory(test.history, 1)
+			if linearizable := err == nil; linearizable != test.linearizable {
+				t.Fatalf("expected linearizable %t, got %v", test.linearizable, err)
+			}
+		})
+	}
+}